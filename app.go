@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go-romm-sync/assets"
 	"go-romm-sync/authsrv"
@@ -10,17 +11,21 @@ import (
 	"go-romm-sync/firmware"
 	"go-romm-sync/library"
 	"go-romm-sync/retroarch"
+	"go-romm-sync/romm"
 	"go-romm-sync/rommsrv"
 	syncSrvPkg "go-romm-sync/sync"
 	"go-romm-sync/types"
 	"go-romm-sync/utils"
+	"go-romm-sync/watcher"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -36,11 +41,16 @@ type App struct {
 	coreResolver  *retroarch.CoreResolver
 	firmwareSrv   *firmware.Service
 	assetSrv      *assets.Service
+	saveWatcher   *watcher.Service
 
 	// Download/Auth protection
 	downloadCancels map[uint]context.CancelFunc
 	downloadMu      sync.Mutex
 	loginMu         sync.Mutex
+
+	// Library-wide sync
+	librarySyncCancel context.CancelFunc
+	librarySyncMu     sync.Mutex
 }
 
 // NewApp creates a new App application struct
@@ -51,7 +61,8 @@ func NewApp(cm *config.ConfigManager) *App {
 	}
 	app.rommSrv = rommsrv.New(app)
 	app.librarySrv = library.New(app.configManager, app.rommSrv, app)
-	app.syncSrv = syncSrvPkg.New(app.librarySrv, app.rommSrv, app)
+	app.syncSrv = syncSrvPkg.New(app.configManager, app.librarySrv, app.rommSrv, app)
+	app.saveWatcher = watcher.New(app.configManager, app.librarySrv, app.syncSrv, app)
 	app.authSrv = authsrv.New(app.configManager, app.rommSrv, app)
 	app.firmwareSrv = firmware.New(app.configManager, app.rommSrv, app)
 	app.assetSrv = assets.New(app, app.rommSrv, app)
@@ -63,6 +74,9 @@ func NewApp(cm *config.ConfigManager) *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if err := a.saveWatcher.Start(); err != nil {
+		a.LogErrorf("Failed to start save watcher: %v", err)
+	}
 }
 
 // --- Wails External API (Thin Wrappers) ---
@@ -88,6 +102,18 @@ func (a *App) SaveConfig(cfg *types.AppConfig) string {
 		updateIfNotEmpty(&current.CheevosUsername, cfg.CheevosUsername)
 		updateIfNotEmpty(&current.CheevosPassword, cfg.CheevosPassword)
 		updateIfNotEmpty(&current.ClientToken, cfg.ClientToken)
+		// An empty device name falls back to the hostname, so it may be cleared.
+		current.DeviceName = cfg.DeviceName
+		if cfg.BackupGenerations > 0 {
+			current.BackupGenerations = cfg.BackupGenerations
+		}
+		if cfg.WatchDebounceSeconds > 0 {
+			current.WatchDebounceSeconds = cfg.WatchDebounceSeconds
+		}
+		// A negative retention switches pruning off again.
+		if cfg.ServerRetention != 0 {
+			current.ServerRetention = cfg.ServerRetention
+		}
 
 		if current.RommHost != oldHost || current.Username != oldUser || current.Password != oldPass {
 			hostOrCredsChanged = true
@@ -190,8 +216,10 @@ func (a *App) GetLibrary(limit, offset, platformID int, search string) (types.Li
 
 		items, total, err := a.rommSrv.GetLibrary(limit, offset, platformID, search)
 		if err != nil {
-			a.handleConnectionError(err)
-			continue
+			if a.handleConnectionError(err) {
+				continue
+			}
+			return types.LibraryResult[types.Game]{}, err
 		}
 		return types.LibraryResult[types.Game]{Items: items, Total: total}, nil
 	}
@@ -206,8 +234,10 @@ func (a *App) GetPlatforms(limit, offset int) (types.LibraryResult[types.Platfor
 
 		items, total, err := a.rommSrv.GetPlatforms(limit, offset)
 		if err != nil {
-			a.handleConnectionError(err)
-			continue
+			if a.handleConnectionError(err) {
+				continue
+			}
+			return types.LibraryResult[types.Platform]{}, err
 		}
 		return types.LibraryResult[types.Platform]{Items: items, Total: total}, nil
 	}
@@ -314,7 +344,13 @@ func (a *App) DownloadRomToLibrary(id uint) error {
 		a.downloadMu.Unlock()
 	}()
 
-	return a.librarySrv.DownloadRomToLibrary(ctx, id)
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	if err := a.librarySrv.DownloadRomToLibrary(ctx, id); err != nil {
+		return err
+	}
+	a.saveWatcher.Refresh()
+	return nil
 }
 
 func (a *App) CancelDownload(id uint) {
@@ -341,6 +377,12 @@ func (a *App) DeleteRom(id uint) error {
 	return a.librarySrv.DeleteRom(id)
 }
 
+// VerifyLibrary rehashes the downloaded ROMs and reports those that are corrupted,
+// modified, or missing.
+func (a *App) VerifyLibrary() ([]library.RomCheck, error) {
+	return a.librarySrv.VerifyLibrary()
+}
+
 func (a *App) OpenGameFolder(game *types.Game) error {
 	romDir := a.librarySrv.GetRomDir(game)
 	if _, err := os.Stat(romDir); os.IsNotExist(err) {
@@ -394,13 +436,191 @@ func (a *App) UploadState(id uint, core, filename string) error {
 }
 
 func (a *App) DownloadServerSave(gameID, serverID uint, core, filename, updatedAt string) error {
+	resume := a.saveWatcher.Pause()
+	defer resume()
 	return a.syncSrv.DownloadServerSave(gameID, serverID, core, filename, updatedAt)
 }
 
 func (a *App) DownloadServerState(gameID, serverID uint, core, filename, updatedAt string) error {
+	resume := a.saveWatcher.Pause()
+	defer resume()
 	return a.syncSrv.DownloadServerState(gameID, serverID, core, filename, updatedAt)
 }
 
+// SyncLibrary reconciles the saves and states of every downloaded game with RomM.
+// Only one library sync can run at a time; CancelLibrarySync stops it.
+func (a *App) SyncLibrary() (syncSrvPkg.LibrarySyncSummary, error) {
+	if a.configManager.GetConfig().OfflineMode {
+		return syncSrvPkg.LibrarySyncSummary{}, fmt.Errorf("library sync is unavailable in offline mode")
+	}
+
+	parentCtx := a.ctx
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	a.librarySyncMu.Lock()
+	if a.librarySyncCancel != nil {
+		a.librarySyncMu.Unlock()
+		return syncSrvPkg.LibrarySyncSummary{}, fmt.Errorf("library sync already in progress")
+	}
+	a.librarySyncCancel = cancel
+	a.librarySyncMu.Unlock()
+
+	defer func() {
+		a.librarySyncMu.Lock()
+		a.librarySyncCancel = nil
+		a.librarySyncMu.Unlock()
+	}()
+
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.SyncLibrary(ctx)
+}
+
+func (a *App) CancelLibrarySync() {
+	a.librarySyncMu.Lock()
+	cancel := a.librarySyncCancel
+	a.librarySyncMu.Unlock()
+
+	if cancel != nil {
+		a.LogInfof("Cancelling library sync")
+		cancel()
+	}
+}
+
+func (a *App) ListBackups(id uint) ([]syncSrvPkg.BackupVersion, error) {
+	return a.syncSrv.ListBackups(id)
+}
+
+func (a *App) RestoreBackup(id uint, backupID string) error {
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.RestoreBackup(id, backupID)
+}
+
+func (a *App) PlanMemoryCardMigration(id uint) ([]syncSrvPkg.CardMigration, error) {
+	return a.syncSrv.PlanMemoryCardMigration(id)
+}
+
+// ImportRetroArchSaves copies the saves and states in RetroArch's save and state
+// directories into the library, uploading them afterwards when upload is set.
+func (a *App) ImportRetroArchSaves(upload bool) (syncSrvPkg.ImportReport, error) {
+	savesDir, statesDir, err := retroarch.FindSaveDirs(a.GetRetroArchPath())
+	if err != nil {
+		return syncSrvPkg.ImportReport{}, fmt.Errorf("failed to locate RetroArch saves: %w", err)
+	}
+	if upload && a.configManager.GetConfig().OfflineMode {
+		a.LogInfof("Offline mode enabled, importing RetroArch saves without uploading them")
+		upload = false
+	}
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.ImportRetroArchSaves(savesDir, statesDir, upload)
+}
+
+// ExportSaveArchive asks for a destination and writes every local save and state
+// into a single zip archive. It returns an empty manifest when the dialog is cancelled.
+func (a *App) ExportSaveArchive() (syncSrvPkg.ExportManifest, error) {
+	if a.ctx == nil {
+		return syncSrvPkg.ExportManifest{}, nil
+	}
+	destPath, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:           "Export Saves and States",
+		DefaultFilename: fmt.Sprintf("romm-saves-%s.zip", time.Now().Format("2006-01-02")),
+		Filters:         []wailsRuntime.FileFilter{{DisplayName: "Zip Archives", Pattern: "*.zip"}},
+	})
+	if err != nil || destPath == "" {
+		return syncSrvPkg.ExportManifest{}, err
+	}
+	return a.syncSrv.ExportSaves(destPath)
+}
+
+// RestoreSaveArchive asks for an archive written by ExportSaveArchive and puts its
+// saves and states back into the library.
+func (a *App) RestoreSaveArchive() (syncSrvPkg.RestoreReport, error) {
+	srcPath, err := a.OpenFileDialog("Select Save Archive", []string{"*.zip"})
+	if err != nil || srcPath == "" {
+		return syncSrvPkg.RestoreReport{}, err
+	}
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.RestoreSaves(srcPath)
+}
+
+func (a *App) PlanCoreSwitch(id uint, fromCore, toCore string) ([]syncSrvPkg.CoreSaveCopy, error) {
+	return a.syncSrv.PlanCoreSwitch(id, fromCore, toCore)
+}
+
+func (a *App) SwitchCoreSaves(id uint, fromCore, toCore string) ([]syncSrvPkg.CoreSaveCopy, error) {
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.SwitchCoreSaves(id, fromCore, toCore)
+}
+
+func (a *App) MigrateMemoryCards(id uint) ([]syncSrvPkg.CardMigration, error) {
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	return a.syncSrv.MigrateMemoryCards(id)
+}
+
+// SetSaveWatcher enables or disables uploading saves in the background as they change.
+func (a *App) SetSaveWatcher(enabled bool) error {
+	if err := a.configManager.Update(func(cfg *types.AppConfig) {
+		cfg.WatchSaves = enabled
+	}); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	if !enabled {
+		a.saveWatcher.Stop()
+		return nil
+	}
+	return a.saveWatcher.Start()
+}
+
+// SetSaveEncryption enables or disables encrypting uploaded saves and states. An
+// empty passphrase keeps the current one, which is still used to decrypt
+// downloads after encryption is disabled.
+func (a *App) SetSaveEncryption(enabled bool, passphrase string) error {
+	var missing bool
+	if err := a.configManager.Update(func(cfg *types.AppConfig) {
+		updateIfNotEmpty(&cfg.EncryptionPassphrase, passphrase)
+		missing = enabled && cfg.EncryptionPassphrase == ""
+		if !missing {
+			cfg.EncryptSaves = enabled
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	if missing {
+		return fmt.Errorf("an encryption passphrase is required to encrypt saves")
+	}
+	return nil
+}
+
+func (a *App) GetSyncStatus(id uint) ([]syncSrvPkg.AssetSyncStatus, error) {
+	return a.syncSrv.GetSyncStatus(id)
+}
+
+// GetMergedAssets returns the local and server saves and states of a game matched
+// up in one list.
+func (a *App) GetMergedAssets(id uint) ([]syncSrvPkg.MergedAsset, error) {
+	return a.syncSrv.GetMergedAssets(id)
+}
+
+// PlanGameSync lists what syncing a game would upload, download, skip or leave in
+// conflict, without changing anything.
+func (a *App) PlanGameSync(id uint) (syncSrvPkg.SyncPlan, error) {
+	return a.syncSrv.PlanGameSync(id)
+}
+
+// PlanLibrarySync lists what SyncLibrary would do, without changing anything.
+func (a *App) PlanLibrarySync() (syncSrvPkg.SyncPlan, error) {
+	return a.syncSrv.PlanLibrarySync()
+}
+
 func (a *App) ValidateAssetPath(core, filename string) (coreBase, fileBase string, err error) {
 	return a.syncSrv.ValidateAssetPath(core, filename)
 }
@@ -504,22 +724,180 @@ func (a *App) PlayRomWithCore(id uint, coreOverride string) error {
 		_ = a.SaveLastUsedCore(platformSlug, coreToSave)
 	}
 
+	a.offerCoreSwitch(id, coreToSave)
+	a.offerMemoryCardMigration(id)
+	a.pullServerSaves(id)
+
+	// Memory cards shared by all games are only watched while this game runs.
+	stopShared := a.saveWatcher.WatchShared(id)
+	upload := a.sessionUploader(id)
+	onExit := func() {
+		stopShared()
+		if upload != nil {
+			upload()
+		}
+	}
+
 	cheevosUser, cheevosPass := a.GetCheevosCredentials()
-	err = retroarch.Launch(a, exePath, romPath, cheevosUser, cheevosPass, coreOverride, platformSlug, a.GetBiosDir())
+	err = retroarch.Launch(a, exePath, romPath, cheevosUser, cheevosPass, coreOverride, platformSlug, a.GetBiosDir(), onExit)
 	if err != nil {
+		stopShared()
 		return fmt.Errorf("failed to launch game: %w", err)
 	}
 
 	return nil
 }
 
-// findRomPath looks for a valid ROM file in the given directory.
+// offerMemoryCardMigration asks to move GameCube memory cards that were stored under
+// the USA folder into the folder matching the game's region. Once declined, it is
+// not offered again for the game.
+func (a *App) offerMemoryCardMigration(id uint) {
+	if a.ctx == nil || slices.Contains(a.configManager.GetConfig().DeclinedCardMoves, id) {
+		return
+	}
+	moves, err := a.syncSrv.PlanMemoryCardMigration(id)
+	if err != nil || len(moves) == 0 {
+		return
+	}
+	message := fmt.Sprintf(
+		"%d memory card save(s) for this game are in Dolphin's %s folder, but the game is a %s release.\n\nMove them to the %s folder?",
+		len(moves), moves[0].From, moves[0].To, moves[0].To,
+	)
+	result, err := wailsRuntime.MessageDialog(a.ctx, wailsRuntime.MessageDialogOptions{
+		Type:    wailsRuntime.QuestionDialog,
+		Title:   "Memory Card Region",
+		Message: message,
+	})
+	if err != nil {
+		a.LogErrorf("Failed to show memory card migration dialog: %v", err)
+		return
+	}
+	if result != "Yes" {
+		if err := a.configManager.Update(func(cfg *types.AppConfig) {
+			cfg.DeclinedCardMoves = append(cfg.DeclinedCardMoves, id)
+		}); err != nil {
+			a.LogErrorf("Failed to remember declined memory card migration for game %d: %v", id, err)
+		}
+		return
+	}
+	if _, err := a.syncSrv.MigrateMemoryCards(id); err != nil {
+		a.LogErrorf("Failed to migrate memory cards for game %d: %v", id, err)
+	}
+}
+
+// offerCoreSwitch asks to copy the saves the game has from another core into the
+// folder of the new core, where they would otherwise not be found. Once declined,
+// the same switch is not offered again for the game.
+func (a *App) offerCoreSwitch(id uint, toCore string) {
+	if a.ctx == nil {
+		return
+	}
+	fromCore, err := a.syncSrv.DetectCoreSwitch(id, toCore)
+	if err != nil || fromCore == "" {
+		return
+	}
+	switchKey := fromCore + ">" + toCore
+	if slices.Contains(a.configManager.GetConfig().DeclinedCoreSwitches[id], switchKey) {
+		return
+	}
+	copies, err := a.syncSrv.PlanCoreSwitch(id, fromCore, toCore)
+	if err != nil || len(copies) == 0 {
+		return
+	}
+	message := fmt.Sprintf(
+		"This game has %d save(s) from %s, which %s will not find.\n\nCopy them to %s's save folder?",
+		len(copies), fromCore, toCore, toCore,
+	)
+	result, err := wailsRuntime.MessageDialog(a.ctx, wailsRuntime.MessageDialogOptions{
+		Type:    wailsRuntime.QuestionDialog,
+		Title:   "Core Changed",
+		Message: message,
+	})
+	if err != nil {
+		a.LogErrorf("Failed to show core switch dialog: %v", err)
+		return
+	}
+	if result != "Yes" {
+		if err := a.configManager.Update(func(cfg *types.AppConfig) {
+			if cfg.DeclinedCoreSwitches == nil {
+				cfg.DeclinedCoreSwitches = make(map[uint][]string)
+			}
+			cfg.DeclinedCoreSwitches[id] = append(cfg.DeclinedCoreSwitches[id], switchKey)
+		}); err != nil {
+			a.LogErrorf("Failed to remember declined core switch for game %d: %v", id, err)
+		}
+		return
+	}
+	if _, err := a.syncSrv.SwitchCoreSaves(id, fromCore, toCore); err != nil {
+		a.LogErrorf("Failed to copy saves from %s to %s for game %d: %v", fromCore, toCore, id, err)
+	}
+}
+
+// pullServerSaves downloads server saves and states that are newer than the local
+// copies before launch. Failures are logged and never block the launch.
+func (a *App) pullServerSaves(id uint) {
+	if a.configManager.GetConfig().OfflineMode {
+		return
+	}
+	a.EventsEmit(constants.EventPlayStatus, "Checking server for newer saves...")
+	resume := a.saveWatcher.Pause()
+	defer resume()
+	if err := a.syncSrv.PullServerUpdates(id, a.confirmServerOverwrite); err != nil {
+		a.LogErrorf("Skipping pre-launch save sync for game %d: %v", id, err)
+	}
+}
+
+// confirmServerOverwrite asks whether a newer server copy should replace a save
+// or state that was also modified locally.
+func (a *App) confirmServerOverwrite(conflict syncSrvPkg.PullConflict) bool {
+	if a.ctx == nil {
+		return false
+	}
+	message := fmt.Sprintf(
+		"%s/%s was changed on this device (%s) and on the server (%s).\n\nReplace the local copy with the server copy?",
+		conflict.Core, conflict.Name, conflict.LocalUpdatedAt, conflict.ServerUpdatedAt,
+	)
+	result, err := wailsRuntime.MessageDialog(a.ctx, wailsRuntime.MessageDialogOptions{
+		Type:    wailsRuntime.QuestionDialog,
+		Title:   "Save Conflict",
+		Message: message,
+	})
+	if err != nil {
+		a.LogErrorf("Failed to show save conflict dialog: %v", err)
+		return false
+	}
+	return result == "Yes"
+}
+
+// sessionUploader snapshots the game's saves and states before launch and returns
+// a callback that uploads whatever changed once RetroArch exits.
+func (a *App) sessionUploader(id uint) func() {
+	snap, err := a.syncSrv.SnapshotGame(id)
+	if err != nil {
+		a.LogErrorf("Failed to snapshot saves for game %d, automatic upload disabled: %v", id, err)
+		return nil
+	}
+	return func() {
+		if a.configManager.GetConfig().OfflineMode {
+			a.LogInfof("Offline mode enabled, skipping automatic save upload for game %d", id)
+			return
+		}
+		a.syncSrv.UploadSessionChanges(snap)
+	}
+}
+
+// findRomPath looks for a valid ROM file in the given directory. A disc playlist
+// is launched instead of the first disc, so discs can be swapped.
 func (a *App) findRomPath(game *types.Game, romDir string) string {
 	files, err := os.ReadDir(romDir)
 	if err != nil {
 		return ""
 	}
 
+	if p := library.FindPlaylist(romDir, files); p != "" {
+		return p
+	}
+
 	if p := a.findCueFile(romDir, files); p != "" {
 		return p
 	}
@@ -603,9 +981,12 @@ func (a *App) ToggleOfflineMode() bool {
 	return newState
 }
 
-func (a *App) handleConnectionError(err error) {
-	if err == nil {
-		return
+// handleConnectionError switches to offline mode when RomM cannot be reached and
+// reports whether it did. Other failures, such as a one-off gateway error or a
+// rejected token, are left to the caller.
+func (a *App) handleConnectionError(err error) bool {
+	if !errors.Is(err, romm.ErrUnreachable) {
+		return false
 	}
 	a.LogErrorf("Server operation failed: %v. Automatically switching to offline mode.", err)
 	if err := a.configManager.Update(func(cfg *types.AppConfig) {
@@ -616,6 +997,7 @@ func (a *App) handleConnectionError(err error) {
 	if a.ctx != nil {
 		wailsRuntime.EventsEmit(a.ctx, "offline-mode-changed", true)
 	}
+	return true
 }
 
 func (a *App) UpdateRetroArchCores() error {
@@ -784,8 +1166,10 @@ func (a *App) GetRom(id uint) (types.Game, error) {
 		}
 		game, err := a.rommSrv.GetRom(id)
 		if err != nil {
-			a.handleConnectionError(err)
-			continue
+			if a.handleConnectionError(err) {
+				continue
+			}
+			return types.Game{}, err
 		}
 		return game, nil
 	}
@@ -804,11 +1188,13 @@ func (a *App) GetLocalGame(id uint) (types.Game, error) {
 }
 
 func (a *App) RomMUploadSave(id uint, core, filename string, content []byte) error {
-	return a.rommSrv.GetClient().UploadSave(id, core, filename, content)
+	_, err := a.rommSrv.GetClient().UploadSave(id, core, filename, content)
+	return err
 }
 
 func (a *App) RomMUploadState(id uint, core, filename string, content []byte) error {
-	return a.rommSrv.GetClient().UploadState(id, core, filename, content)
+	_, err := a.rommSrv.GetClient().UploadState(id, core, filename, content)
+	return err
 }
 
 func (a *App) RomMDownloadSave(ctx context.Context, id uint) (reader io.ReadCloser, filename string, err error) {
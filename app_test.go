@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"go-romm-sync/config"
 	"go-romm-sync/constants"
 	"go-romm-sync/retroarch"
+	"go-romm-sync/romm"
 	"go-romm-sync/types"
 	"os"
 	"path/filepath"
@@ -32,7 +34,8 @@ func TestSaveConfigMerge(t *testing.T) {
 
 	// 1. Test partial update
 	update := types.AppConfig{
-		Username: "new-user",
+		Username:   "new-user",
+		DeviceName: "living-room",
 		// RommHost is empty, should be preserved
 	}
 
@@ -48,6 +51,15 @@ func TestSaveConfigMerge(t *testing.T) {
 	if finalCfg.RommHost != "http://initial.com" {
 		t.Errorf("Expected host to be preserved as http://initial.com, got %s", finalCfg.RommHost)
 	}
+	if finalCfg.DeviceName != "living-room" {
+		t.Errorf("Expected device name living-room, got %s", finalCfg.DeviceName)
+	}
+
+	// 2. The device name can be cleared again
+	app.SaveConfig(&types.AppConfig{Username: "new-user"})
+	if finalCfg = cm.GetConfig(); finalCfg.DeviceName != "" {
+		t.Errorf("Expected device name to be cleared, got %s", finalCfg.DeviceName)
+	}
 }
 
 func TestLogout(t *testing.T) {
@@ -453,3 +465,25 @@ func (m *MockAppForTest) GetRom(id uint) (types.Game, error) {
 func (m *MockAppForTest) GetRomMHost() string { return "" }
 func (m *MockAppForTest) GetUsername() string { return "" }
 func (m *MockAppForTest) GetPassword() string { return "" }
+
+func TestHandleConnectionError(t *testing.T) {
+	cm := config.NewConfigManager()
+	cm.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	cm.Config = &types.AppConfig{}
+	app := NewApp(cm)
+
+	for _, err := range []error{
+		&romm.Error{Kind: romm.ErrServer, StatusCode: 502, Message: "library fetch failed with status 502"},
+		&romm.Error{Kind: romm.ErrTimeout, Message: "failed to perform library request"},
+		&romm.Error{Kind: romm.ErrUnauthorized, Message: "not authenticated"},
+	} {
+		if app.handleConnectionError(err) || cm.GetConfig().OfflineMode {
+			t.Errorf("Expected %v not to switch to offline mode", err)
+		}
+	}
+
+	err := fmt.Errorf("failed to fetch library: %w", &romm.Error{Kind: romm.ErrUnreachable, Message: "failed to perform library request"})
+	if !app.handleConnectionError(err) || !cm.GetConfig().OfflineMode {
+		t.Error("Expected an unreachable server to switch to offline mode")
+	}
+}
@@ -2,7 +2,7 @@ package authsrv
 
 import (
 	"fmt"
-	"os"
+	"sync"
 
 	"go-romm-sync/config"
 	"go-romm-sync/constants"
@@ -16,19 +16,68 @@ type Service struct {
 	config *config.ConfigManager
 	romm   *rommsrv.Service
 	ui     types.UIProvider
+
+	mu           sync.Mutex
+	authRequired bool // re-login failed; only an explicit Login tries again
 }
 
-// New creates a new Auth service.
+// New creates a new Auth service and hooks its re-login into the RomM client.
 func New(cfg *config.ConfigManager, romm *rommsrv.Service, ui types.UIProvider) *Service {
-	return &Service{config: cfg, romm: romm, ui: ui}
+	s := &Service{config: cfg, romm: romm, ui: ui}
+	romm.SetReauthenticator(s.Reauthenticate)
+	return s
 }
 
 // Login authenticates with RomM, attempting to upgrade to a persistent client token.
 // Returns "persistent_token" if a client token is active, otherwise the session token.
 func (s *Service) Login() (string, error) {
+	token, err := s.login("")
+	if err == nil {
+		s.mu.Lock()
+		s.authRequired = false
+		s.mu.Unlock()
+	}
+	return token, err
+}
+
+// Reauthenticate logs in again after RomM rejected the current token. A rejected
+// persistent client token is dropped so a password login can mint a new one. When
+// logging in fails, constants.EventAuthRequired is emitted and further attempts are
+// skipped until Login succeeds, so bad credentials are not retried on every request.
+func (s *Service) Reauthenticate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.authRequired {
+		return fmt.Errorf("RomM login required")
+	}
+
+	rejected := s.romm.GetClient().Token()
+	if rejected != "" && rejected == s.config.GetConfig().ClientToken {
+		s.ui.LogErrorf("RomM rejected the persistent Client Token, logging in again")
+		if err := s.config.Update(func(c *types.AppConfig) {
+			c.ClientToken = ""
+		}); err != nil {
+			s.ui.LogErrorf("Failed to clear rejected Client Token: %v", err)
+		}
+	} else {
+		s.ui.LogInfof("RomM session expired, logging in again")
+	}
+
+	if _, err := s.login(rejected); err != nil {
+		s.authRequired = true
+		s.ui.LogErrorf("Failed to log in to RomM again: %v", err)
+		s.ui.EventsEmit(constants.EventAuthRequired, err.Error())
+		return err
+	}
+	return nil
+}
+
+// login signs in with the stored client token or else the password. The rejected
+// token is never tried again, even when it could not be cleared from the config.
+func (s *Service) login(rejected string) (string, error) {
 	// 1. Check for an existing persistent client token
 	cfg := s.config.GetConfig()
-	if cfg.ClientToken != "" && len(cfg.ClientToken) > 4 {
+	if cfg.ClientToken != "" && len(cfg.ClientToken) > 4 && cfg.ClientToken != rejected {
 		s.romm.SetClientToken(cfg.ClientToken)
 
 		// Verify it still works
@@ -47,11 +96,7 @@ func (s *Service) Login() (string, error) {
 	}
 
 	// 3. Attempt upgrade to persistent client token
-	hostname, _ := os.Hostname()
-	if hostname == "" {
-		hostname = "Go-RomM-Sync Client"
-	}
-	tokenName := fmt.Sprintf("Go-RomM-Sync (%s)", hostname)
+	tokenName := fmt.Sprintf("Go-RomM-Sync (%s)", s.config.DeviceName())
 
 	s.ui.LogInfof("Attempting to auto-upgrade to persistent RomM Client Token: %s", tokenName)
 	clientToken, err := s.romm.CreateClientToken(tokenName, constants.RomMDefaultScopes)
@@ -0,0 +1,102 @@
+package authsrv
+
+import (
+	"encoding/json"
+	"errors"
+	"go-romm-sync/config"
+	"go-romm-sync/constants"
+	"go-romm-sync/romm"
+	"go-romm-sync/rommsrv"
+	"go-romm-sync/types"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+type mockConfig struct{ cm *config.ConfigManager }
+
+func (m mockConfig) GetRomMHost() string    { return m.cm.GetConfig().RommHost }
+func (m mockConfig) GetUsername() string    { return m.cm.GetConfig().Username }
+func (m mockConfig) GetPassword() string    { return m.cm.GetConfig().Password }
+func (m mockConfig) GetClientToken() string { return m.cm.GetConfig().ClientToken }
+
+type mockUI struct{ events []string }
+
+func (m *mockUI) LogInfof(format string, args ...interface{})  {}
+func (m *mockUI) LogErrorf(format string, args ...interface{}) {}
+func (m *mockUI) EventsEmit(eventName string, args ...interface{}) {
+	m.events = append(m.events, eventName)
+}
+
+func TestReauthenticate(t *testing.T) {
+	var logins atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/token":
+			logins.Add(1)
+			if r.FormValue("password") != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "session"})
+		case "/api/client-tokens":
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]string{"raw_token": "minted"})
+		default:
+			if auth := r.Header.Get("Authorization"); auth != "Bearer minted" && auth != "Bearer session" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		}
+	}))
+	defer server.Close()
+
+	cm := config.NewConfigManager()
+	cm.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	cm.Config = &types.AppConfig{RommHost: server.URL, Username: "user", Password: "pass", ClientToken: "revoked"}
+	rommSrv := rommsrv.New(mockConfig{cm})
+	ui := &mockUI{}
+	s := New(cm, rommSrv, ui)
+
+	// A revoked client token is replaced and the request replayed.
+	if _, err := rommSrv.GetRom(1); err != nil {
+		t.Fatalf("Expected the request to succeed after logging in again, got %v", err)
+	}
+	if got := cm.GetConfig().ClientToken; got != "minted" {
+		t.Errorf("Expected the new client token to be saved, got %q", got)
+	}
+
+	// With a wrong password the user is asked to log in, once.
+	cm.Config.ClientToken = "revoked"
+	cm.Config.Password = "wrong"
+	rommSrv.SetClientToken("revoked")
+	logins.Store(0)
+	if _, err := rommSrv.GetRom(1); !errors.Is(err, romm.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := rommSrv.GetRom(1); !errors.Is(err, romm.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+	authRequired := 0
+	for _, e := range ui.events {
+		if e == constants.EventAuthRequired {
+			authRequired++
+		}
+	}
+	if authRequired != 1 || logins.Load() != 1 {
+		t.Errorf("Expected one login attempt and one %s event, got %d and %v", constants.EventAuthRequired, logins.Load(), ui.events)
+	}
+
+	// Logging in explicitly re-enables re-authentication.
+	cm.Config.Password = "pass"
+	if _, err := s.Login(); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	rommSrv.SetClientToken("revoked")
+	if _, err := rommSrv.GetRom(1); err != nil {
+		t.Errorf("Expected re-authentication to work again, got %v", err)
+	}
+}
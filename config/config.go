@@ -7,6 +7,7 @@ import (
 	"go-romm-sync/types"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -116,6 +117,18 @@ func (cm *ConfigManager) Update(fn func(*types.AppConfig)) error {
 	return os.WriteFile(cm.ConfigPath, data, 0o644)
 }
 
+// DeviceName returns the name this machine uses towards RomM: the configured
+// device name, or else the hostname.
+func (cm *ConfigManager) DeviceName() string {
+	if name := strings.TrimSpace(cm.GetConfig().DeviceName); name != "" {
+		return name
+	}
+	if hostname, _ := os.Hostname(); hostname != "" {
+		return hostname
+	}
+	return "Go-RomM-Sync Client"
+}
+
 // GetDefaultLibraryPath returns the cross-platform default library path
 func GetDefaultLibraryPath() (string, error) {
 	home, err := os.UserHomeDir()
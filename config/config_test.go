@@ -120,3 +120,16 @@ func TestGetDefaultLibraryPath(t *testing.T) {
 		t.Errorf("Expected absolute path, got %s", path)
 	}
 }
+
+func TestDeviceName(t *testing.T) {
+	cm := &ConfigManager{Config: &types.AppConfig{DeviceName: "  Steam Deck "}}
+	if got := cm.DeviceName(); got != "Steam Deck" {
+		t.Errorf("Expected the configured name, got %q", got)
+	}
+
+	cm.Config.DeviceName = ""
+	hostname, _ := os.Hostname()
+	if got := cm.DeviceName(); hostname != "" && got != hostname {
+		t.Errorf("Expected the hostname %q, got %q", hostname, got)
+	}
+}
@@ -19,6 +19,18 @@ const (
 	EventPlayStatus  = "play-status"
 	EventGameStarted = "game-started"
 	EventGameExited  = "game-exited"
+
+	// EventSaveSyncResult reports the outcome of each automatic save/state upload.
+	EventSaveSyncResult = "save-sync-result"
+	// EventLibrarySyncProgress reports progress of a library-wide sync after each game.
+	EventLibrarySyncProgress = "library-sync-progress"
+	// EventUploadProgress reports the bytes sent while a save or state is uploaded.
+	EventUploadProgress = "upload-progress"
+	// EventAuthRequired reports that RomM rejected the session and logging in again
+	// failed, so the user has to sign in.
+	EventAuthRequired = "auth-required"
+	// EventLibraryVerifyProgress reports the result for each ROM while the library is verified.
+	EventLibraryVerifyProgress = "library-verify-progress"
 )
 
 // Directory Categories
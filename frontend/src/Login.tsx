@@ -14,6 +14,7 @@ function Login({ onLoginSuccess }: LoginProps) {
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
     const [clientToken, setClientToken] = useState('');
+    const [deviceName, setDeviceName] = useState('');
 
     // UI state
     const [isLoggingIn, setIsLoggingIn] = useState(false);
@@ -28,6 +29,7 @@ function Login({ onLoginSuccess }: LoginProps) {
             if (config.username) setUsername(config.username);
             if (config.password) setPassword(config.password);
             if (config.client_token) setClientToken(config.client_token);
+            if (config.device_name) setDeviceName(config.device_name);
         });
     }, []);
 
@@ -51,7 +53,8 @@ function Login({ onLoginSuccess }: LoginProps) {
             romm_host: server,
             username: username,
             password: password,
-            client_token: clientToken
+            client_token: clientToken,
+            device_name: deviceName
         });
 
         // First save the config (backend handles merging)
@@ -46,6 +46,7 @@ function Settings({ isActive = false, onLogout }: SettingsProps) {
     const [cheevosPass, setCheevosPass] = useState('');
     const [offlineMode, setOfflineMode] = useState(false);
     const [clientToken, setClientToken] = useState('');
+    const [deviceName, setDeviceName] = useState('');
     const [isSyncing, setIsSyncing] = useState(false);
     const [isUpdatingCores, setIsUpdatingCores] = useState(false);
     const [isUpdatingBios, setIsUpdatingBios] = useState(false);
@@ -62,7 +63,8 @@ function Settings({ isActive = false, onLogout }: SettingsProps) {
                 cheevos_username = '',
                 cheevos_password = '',
                 offline_mode = false,
-                client_token = ''
+                client_token = '',
+                device_name = ''
             } = cfg || {};
             setConfig(cfg);
             setRaPath(retroarch_path);
@@ -71,6 +73,7 @@ function Settings({ isActive = false, onLogout }: SettingsProps) {
             setCheevosPass(cheevos_password);
             setOfflineMode(offline_mode);
             setClientToken(client_token);
+            setDeviceName(device_name);
         });
     }, []);
 
@@ -157,7 +160,8 @@ function Settings({ isActive = false, onLogout }: SettingsProps) {
             library_path: libPath,
             cheevos_username: cheevosUser,
             cheevos_password: cheevosPass,
-            client_token: clientToken
+            client_token: clientToken,
+            device_name: deviceName
         });
 
         SaveConfig(updatedConfig)
@@ -312,6 +316,8 @@ function Settings({ isActive = false, onLogout }: SettingsProps) {
                     <RomMConnectionSection
                         clientToken={clientToken}
                         setClientToken={setClientToken}
+                        deviceName={deviceName}
+                        setDeviceName={setDeviceName}
                     />
 
                     <div className="settings-actions">
@@ -628,9 +634,11 @@ function RetroAchievementsSection({
 interface RomMConnectionSectionProps {
     clientToken: string;
     setClientToken: (val: string) => void;
+    deviceName: string;
+    setDeviceName: (val: string) => void;
 }
 
-function RomMConnectionSection({ clientToken, setClientToken }: RomMConnectionSectionProps) {
+function RomMConnectionSection({ clientToken, setClientToken, deviceName, setDeviceName }: RomMConnectionSectionProps) {
     return (
         <div className="settings-card">
             <div className="settings-section-title">RomM Connection</div>
@@ -649,6 +657,21 @@ function RomMConnectionSection({ clientToken, setClientToken }: RomMConnectionSe
                     A persistent token for stable connection. The app can auto-generate this if you login normally, or you can paste one from RomM Settings.
                 </div>
             </div>
+            <div className="input-group">
+                <label htmlFor="deviceName">Device Name</label>
+                <FocusableInput
+                    id="deviceName"
+                    focusKey="device-name-input"
+                    className="input"
+                    value={deviceName}
+                    onChange={(e) => setDeviceName(e.target.value)}
+                    autoComplete="off"
+                    placeholder="Hostname"
+                />
+                <div className="input-help-text" style={{ fontSize: '0.8rem', opacity: 0.7, marginTop: '0.5rem' }}>
+                    Tags the saves and states this machine uploads. Leave empty to use the hostname.
+                </div>
+            </div>
         </div>
     );
 }
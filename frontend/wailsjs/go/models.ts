@@ -13,6 +13,15 @@ export namespace types {
 	    platform_firmware: Record<string, number>;
 	    offline_mode: boolean;
 	    client_token: string;
+	    backup_generations: number;
+	    watch_saves: boolean;
+	    watch_debounce_seconds: number;
+	    server_retention: number;
+	    device_name: string;
+	    encrypt_saves: boolean;
+	    encryption_passphrase: string;
+	    declined_card_moves: number[];
+	    declined_core_switches: Record<number, Array<string>>;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppConfig(source);
@@ -32,6 +41,15 @@ export namespace types {
 	        this.platform_firmware = source["platform_firmware"];
 	        this.offline_mode = source["offline_mode"];
 	        this.client_token = source["client_token"];
+	        this.backup_generations = source["backup_generations"];
+	        this.watch_saves = source["watch_saves"];
+	        this.watch_debounce_seconds = source["watch_debounce_seconds"];
+	        this.server_retention = source["server_retention"];
+	        this.device_name = source["device_name"];
+	        this.encrypt_saves = source["encrypt_saves"];
+	        this.encryption_passphrase = source["encryption_passphrase"];
+	        this.declined_card_moves = source["declined_card_moves"];
+	        this.declined_core_switches = source["declined_core_switches"];
 	    }
 	}
 	export class FileItem {
@@ -6,6 +6,7 @@ toolchain go1.26.5
 
 require (
 	github.com/bodgit/sevenzip v1.6.5
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/nwaples/rardecode/v2 v2.2.5
 	github.com/wailsapp/wails/v2 v2.13.0
 )
@@ -0,0 +1,228 @@
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-romm-sync/romm"
+	"go-romm-sync/types"
+	"go-romm-sync/utils"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// partSuffix marks a ROM that is still being downloaded.
+	partSuffix = ".part"
+	// partInfoSuffix marks the sidecar of a .part file describing the download, so
+	// it is only resumed while the file on the server is unchanged.
+	partInfoSuffix = ".json"
+	// downloadAttempts is how often an interrupted download is resumed before
+	// giving up.
+	downloadAttempts = 3
+)
+
+// partInfo is the sidecar of a partial download.
+type partInfo struct {
+	Size         int64  `json:"size"` // -1 when unknown
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator returns the value to send in If-Range. Weak ETags may not be used for
+// ranges, so Last-Modified is used for them instead.
+func (p *partInfo) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+func readPartInfo(partPath string) *partInfo {
+	data, err := os.ReadFile(partPath + partInfoSuffix)
+	if err != nil {
+		return nil
+	}
+	var info partInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil
+	}
+	return &info
+}
+
+func writePartInfo(partPath string, info *partInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partPath+partInfoSuffix, data, 0o644)
+}
+
+// resumeOffset returns where a download into partPath can continue, or 0 when it
+// has to start over.
+func resumeOffset(partPath string, info *partInfo) int64 {
+	if info == nil || info.validator() == "" {
+		return 0
+	}
+	stat, err := os.Stat(partPath)
+	if err != nil {
+		return 0
+	}
+	if info.Size >= 0 && stat.Size() > info.Size {
+		return 0
+	}
+	return stat.Size()
+}
+
+// romTarget is a file of a ROM as it is stored in the library.
+type romTarget struct {
+	path string
+	file *types.RomFile // nil for a ROM that is a single file
+
+	crc, md5, sha1 string
+}
+
+// archiveExts are the formats RomM hashes by their decompressed content, so their
+// hashes never match the downloaded file.
+var archiveExts = map[string]bool{".zip": true, ".7z": true, ".tar": true, ".gz": true, ".bz2": true}
+
+// archived reports whether the file is an archive, which is not verified.
+func (t *romTarget) archived() bool {
+	return archiveExts[strings.ToLower(filepath.Ext(t.path))]
+}
+
+// hasher returns the hasher verifying the file. It checks nothing for archives.
+func (t *romTarget) hasher() *romHasher {
+	if t.archived() {
+		return newRomHasher("", "", "")
+	}
+	return newRomHasher(t.crc, t.md5, t.sha1)
+}
+
+// singleDownload reports whether a ROM is downloaded as one file: it is not a
+// folder, or RomM did not list the folder's files.
+func singleDownload(game *types.Game) bool {
+	return !game.IsFolder() || len(game.Files) == 0
+}
+
+// romTargets returns the files a ROM is stored as in romDir: the downloaded file
+// itself, or, for a ROM that RomM stores as a folder of files, each of those files
+// at its place in the folder.
+func romTargets(game *types.Game, romDir string) ([]romTarget, error) {
+	if singleDownload(game) {
+		return []romTarget{{
+			path: filepath.Join(romDir, filepath.Base(game.FullPath)),
+			crc:  game.CRCHash, md5: game.MD5Hash, sha1: game.SHA1Hash,
+		}}, nil
+	}
+
+	targets := make([]romTarget, 0, len(game.Files))
+	for i := range game.Files {
+		f := &game.Files[i]
+		rel, ok := strings.CutPrefix(f.FullPath, game.FullPath+"/")
+		if !ok || rel == "" {
+			rel = f.FileName
+		}
+		path := filepath.Join(romDir, utils.SanitizePath(rel))
+		if path == romDir || !utils.IsSafePath(romDir, path) {
+			return nil, fmt.Errorf("invalid path traversal detected in %s", f.FullPath)
+		}
+		targets = append(targets, romTarget{
+			path: path,
+			file: f,
+			crc:  f.CRCHash, md5: f.MD5Hash, sha1: f.SHA1Hash,
+		})
+	}
+	return targets, nil
+}
+
+// fetchFunc requests a file of a ROM from offset on, see romm.Client.DownloadFileRange.
+type fetchFunc func(offset int64, ifRange string) (*romm.RomDownload, error)
+
+// downloadPart downloads a file into partPath, continuing a previous partial
+// download when the server still has the same file. It succeeds only once the
+// file has its full size; by then the hasher has seen all of it. Progress is
+// reported after the done bytes of earlier files.
+func (s *Service) downloadPart(fetch fetchFunc, partPath string, done int64, pw *ProgressWriter, hasher *romHasher) error {
+	info := readPartInfo(partPath)
+	offset := resumeOffset(partPath, info)
+	hasher.Reset()
+	if offset > 0 && offset == info.Size {
+		return hasher.hashFile(partPath, offset)
+	}
+
+	validator := ""
+	if offset > 0 {
+		validator = info.validator()
+	}
+	d, err := fetch(offset, validator)
+	var rommErr *romm.Error
+	if offset > 0 && errors.As(err, &rommErr) && rommErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		s.ui.LogInfof("DownloadRomToLibrary: Server rejected resuming %s, starting over", partPath)
+		d, err = fetch(0, "")
+	}
+	if err != nil {
+		return err
+	}
+	defer d.Body.Close() //nolint:errcheck
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if d.Offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+		s.ui.LogInfof("DownloadRomToLibrary: Resuming %s at %d of %d bytes", partPath, d.Offset, d.Size)
+	} else {
+		if offset > 0 {
+			s.ui.LogInfof("DownloadRomToLibrary: %s changed on the server, starting over", partPath)
+		}
+		info = &partInfo{Size: d.Size, ETag: d.ETag, LastModified: d.LastModified}
+		if err := writePartInfo(partPath, info); err != nil {
+			return fmt.Errorf("failed to record download: %w", err)
+		}
+	}
+
+	out, err := os.OpenFile(partPath, flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	if pw.Total <= 0 && d.Size > 0 {
+		pw.Total = done + d.Size
+	}
+	pw.Downloaded = done + d.Offset
+	if d.Offset > 0 {
+		if err := hasher.hashFile(partPath, d.Offset); err != nil {
+			_ = out.Close()
+			return err
+		}
+	}
+	n, err := io.Copy(io.MultiWriter(out, pw, hasher), d.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if size := d.Offset + n; info.Size >= 0 && size != info.Size {
+		return fmt.Errorf("%w: download ended after %d of %d bytes", io.ErrUnexpectedEOF, size, info.Size)
+	}
+	return nil
+}
+
+// resumableDownloadError reports whether a failed download may succeed when it is
+// resumed: the connection failed or the server had a temporary problem, rather
+// than the download being cancelled, refused, or failing to write locally.
+func resumableDownloadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return false
+	}
+	return !errors.Is(err, romm.ErrUnauthorized) && !errors.Is(err, romm.ErrNotFound) && !errors.Is(err, romm.ErrRejected)
+}
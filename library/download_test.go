@@ -0,0 +1,247 @@
+package library
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-romm-sync/config"
+	"go-romm-sync/rommsrv"
+	"go-romm-sync/types"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const romContent = "0123456789abcdefghij"
+
+func romGame() types.Game {
+	return types.Game{ID: 1, FullPath: "SNES/Game.sfc", FileSize: int64(len(romContent)), SHA1Hash: hashHex(sha1.New(), romContent)}
+}
+
+func hashHex(h hash.Hash, content string) string {
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newRomServer serves romContent as the ROM of game with ETag "v1". The first cut
+// downloads of the content stop halfway through.
+func newRomServer(t *testing.T, romGame types.Game, cut int, ranges *[]string) *httptest.Server {
+	t.Helper()
+	game, _ := json.Marshal(romGame)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/content/") {
+			_, _ = w.Write(game)
+			return
+		}
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if cut > 0 {
+			cut--
+			w.Header().Set("Content-Length", strconv.Itoa(len(romContent)))
+			_, _ = w.Write([]byte(romContent[:len(romContent)/2]))
+			return
+		}
+		http.ServeContent(w, r, "Game.sfc", time.Time{}, strings.NewReader(romContent))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newDownloadService(t *testing.T, serverURL string) (*Service, string) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cm := config.NewConfigManager()
+	cm.ConfigPath = filepath.Join(tempDir, "config.json")
+	cm.Config = &types.AppConfig{LibraryPath: tempDir}
+	rommSrv := rommsrv.New(mockRommConfig{})
+	rommSrv.GetClient().BaseURL = serverURL
+	return New(cm, rommSrv, &MockUIProvider{}), filepath.Join(tempDir, "SNES", "1", "Game.sfc")
+}
+
+func TestDownloadRomToLibrary_Resume(t *testing.T) {
+	tests := []struct {
+		name       string
+		cut        int
+		part       string // content of an earlier partial download
+		etag       string // ETag recorded for it
+		wantRanges []string
+	}{
+		{name: "interrupted", cut: 1, wantRanges: []string{"", "bytes=10-"}},
+		{name: "earlier partial download", part: romContent[:5], etag: `"v1"`, wantRanges: []string{"bytes=5-"}},
+		{name: "changed on server", part: "XXXXX", etag: `"v0"`, wantRanges: []string{"bytes=5-"}},
+		{name: "partial download without sidecar", part: "XXXXX", wantRanges: []string{""}},
+		{name: "keeps failing", cut: downloadAttempts, wantRanges: []string{"", "bytes=10-", "bytes=10-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			server := newRomServer(t, romGame(), tt.cut, &ranges)
+			s, destPath := newDownloadService(t, server.URL)
+			partPath := destPath + partSuffix
+			if tt.part != "" {
+				_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+				_ = os.WriteFile(partPath, []byte(tt.part), 0o644)
+				if tt.etag != "" {
+					_ = writePartInfo(partPath, &partInfo{Size: int64(len(romContent)), ETag: tt.etag})
+				}
+			}
+
+			err := s.DownloadRomToLibrary(context.Background(), 1)
+			if strings.Join(ranges, ",") != strings.Join(tt.wantRanges, ",") {
+				t.Errorf("Expected ranges %q, got %q", tt.wantRanges, ranges)
+			}
+			if tt.cut >= downloadAttempts {
+				if err == nil {
+					t.Fatal("Expected the download to fail")
+				}
+				if _, err := os.Stat(destPath); err == nil {
+					t.Error("Expected no ROM file after a failed download")
+				}
+				if data, _ := os.ReadFile(partPath); string(data) != romContent[:10] {
+					t.Errorf("Expected the partial download to be kept, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DownloadRomToLibrary failed: %v", err)
+			}
+			if data, _ := os.ReadFile(destPath); string(data) != romContent {
+				t.Errorf("Expected %q, got %q", romContent, data)
+			}
+			for _, p := range []string{partPath, partPath + partInfoSuffix} {
+				if _, err := os.Stat(p); err == nil {
+					t.Errorf("Expected %s to be removed", p)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadRomToLibrary_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		game    func(*types.Game)
+		cut     int
+		wantErr bool
+	}{
+		{name: "all hashes match", cut: 1, game: func(g *types.Game) {
+			g.CRCHash = hashHex(crc32.NewIEEE(), romContent)
+			g.MD5Hash = strings.ToUpper(hashHex(md5.New(), romContent))
+		}},
+		{name: "no hashes", game: func(g *types.Game) { g.SHA1Hash = "" }},
+		{name: "corrupt", wantErr: true, game: func(g *types.Game) { g.SHA1Hash = hashHex(sha1.New(), "other") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := romGame()
+			tt.game(&game)
+			var ranges []string
+			server := newRomServer(t, game, tt.cut, &ranges)
+			s, destPath := newDownloadService(t, server.URL)
+			partPath := destPath + partSuffix
+
+			err := s.DownloadRomToLibrary(context.Background(), 1)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("DownloadRomToLibrary failed: %v", err)
+				}
+				if _, err := os.Stat(destPath); err != nil {
+					t.Errorf("Expected the ROM at %s", destPath)
+				}
+				return
+			}
+			if !errors.Is(err, ErrHashMismatch) {
+				t.Fatalf("Expected ErrHashMismatch, got %v", err)
+			}
+			if _, err := os.Stat(destPath); err == nil {
+				t.Error("Expected no ROM file after a corrupt download")
+			}
+			if data, _ := os.ReadFile(partPath); string(data) != romContent {
+				t.Errorf("Expected the corrupt download to be kept, got %q", data)
+			}
+			if _, err := os.Stat(partPath + partInfoSuffix); err == nil {
+				t.Error("Expected the next download to start over")
+			}
+		})
+	}
+}
+
+func TestDownloadRomToLibrary_ZippedRom(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("Game.sfc")
+	_, _ = w.Write([]byte(romContent))
+	_ = zw.Close()
+	zipped := buf.String()
+
+	// RomM hashes the content inside the archive, not the archive itself.
+	game := types.Game{ID: 1, FullPath: "SNES/Game.zip", FileSize: int64(len(zipped)), SHA1Hash: hashHex(sha1.New(), romContent)}
+	meta, _ := json.Marshal(game)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/content/") {
+			_, _ = w.Write(meta)
+			return
+		}
+		http.ServeContent(w, r, "Game.zip", time.Time{}, strings.NewReader(zipped))
+	}))
+	t.Cleanup(server.Close)
+	s, destPath := newDownloadService(t, server.URL)
+	zipPath := filepath.Join(filepath.Dir(destPath), "Game.zip")
+
+	if err := s.DownloadRomToLibrary(context.Background(), 1); err != nil {
+		t.Fatalf("Expected the zipped ROM to download, got %v", err)
+	}
+	if data, _ := os.ReadFile(zipPath); string(data) != zipped {
+		t.Errorf("Expected the archive at %s", zipPath)
+	}
+	if check := s.verifyRom(&game); check.Status != VerifySkipped {
+		t.Errorf("Expected the archive to be skipped by the library check, got %s (%s)", check.Status, check.Detail)
+	}
+}
+
+func TestRomTargets(t *testing.T) {
+	romDir := filepath.Join("lib", "snes", "1")
+	file := types.RomFile{ID: 7, FileName: "Game.sfc", FullPath: "snes/Game/Game.sfc"}
+	tests := []struct {
+		name string
+		game types.Game
+		want []string
+	}{
+		{"single file", types.Game{FullPath: "snes/Game.sfc", Files: []types.RomFile{{FileName: "Game.sfc", FullPath: "snes/Game.sfc"}}}, []string{"Game.sfc"}},
+		// RomM serves a folder as a zip, even when it holds a single file.
+		{"folder with one file", types.Game{FullPath: "snes/Game", Files: []types.RomFile{file}, HasNestedSingleFile: true}, []string{"Game.sfc"}},
+		{"folder from older RomM", types.Game{FullPath: "snes/Game", Files: []types.RomFile{file}, Multi: true}, []string{"Game.sfc"}},
+		{"folder without files", types.Game{FullPath: "snes/Game", HasMultipleFiles: true}, []string{"Game"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := romTargets(&tt.game, romDir)
+			if err != nil {
+				t.Fatalf("romTargets failed: %v", err)
+			}
+			if len(targets) != len(tt.want) {
+				t.Fatalf("Expected %d targets, got %+v", len(tt.want), targets)
+			}
+			for i, want := range tt.want {
+				if targets[i].path != filepath.Join(romDir, want) {
+					t.Errorf("Expected %s, got %s", want, targets[i].path)
+				}
+				if tt.game.IsFolder() && len(tt.game.Files) > 0 && targets[i].file == nil {
+					t.Errorf("Expected %s to be downloaded as a file of the folder", want)
+				}
+			}
+		})
+	}
+}
@@ -3,16 +3,15 @@ package library
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"go-romm-sync/config"
 	"go-romm-sync/constants"
 	"go-romm-sync/retroarch"
+	"go-romm-sync/romm"
 	"go-romm-sync/rommsrv"
 	"go-romm-sync/types"
 	"go-romm-sync/utils"
 	"go-romm-sync/utils/archive"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -70,7 +69,12 @@ func (s *Service) GetRomDir(game *types.Game) string {
 	return filepath.Join(libPath, relPath, fmt.Sprintf("%d", game.ID))
 }
 
-// DownloadRomToLibrary downloads a ROM directly to the configured library path.
+// DownloadRomToLibrary downloads a ROM to the configured library path. A ROM that
+// RomM stores as a folder of files is downloaded file by file. Each file is
+// downloaded next to its destination with a .part suffix and only moved into place
+// once complete and matching the hashes RomM reported, so an interrupted download
+// resumes where it stopped, both when it is retried right away and when it is
+// started again later.
 func (s *Service) DownloadRomToLibrary(ctx context.Context, id uint) error {
 	libPath := s.config.GetConfig().LibraryPath
 	if libPath == "" {
@@ -84,40 +88,16 @@ func (s *Service) DownloadRomToLibrary(ctx context.Context, id uint) error {
 		return fmt.Errorf("failed to get ROM info: %w", err)
 	}
 
-	reader, _, err := s.romm.GetClient().DownloadFile(ctx, &game)
+	destDir := s.GetRomDir(&game)
+	targets, err := romTargets(&game, destDir)
 	if err != nil {
 		return err
 	}
-	defer reader.Close() //nolint:errcheck
-
-	destDir := s.GetRomDir(&game)
-	filename := filepath.Base(game.FullPath)
-	destPath := filepath.Join(destDir, filename)
 
 	if err := os.MkdirAll(destDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	var downloadSuccess bool
-	defer func() {
-		if !downloadSuccess {
-			if _, err := os.Stat(destPath); err == nil {
-				s.ui.LogInfof("DownloadRomToLibrary: Cleaning up partial/failed download at %s", destPath)
-				_ = os.Remove(destPath) // Ignore error as it's just cleanup
-			}
-		}
-	}()
-
-	out, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer func() {
-		if err := out.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
-			s.ui.LogErrorf("DownloadRomToLibrary: Failed to close destination file: %v", err)
-		}
-	}()
-
 	pw := &ProgressWriter{
 		Total:    game.FileSize,
 		GameID:   game.ID,
@@ -125,19 +105,83 @@ func (s *Service) DownloadRomToLibrary(ctx context.Context, id uint) error {
 		LastEmit: time.Now(),
 	}
 
-	s.ui.LogInfof("DownloadRomToLibrary: Starting download for ID %d, Size: %d", id, game.FileSize)
-	if _, err := io.Copy(io.MultiWriter(out, pw), reader); err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
+	s.ui.LogInfof("DownloadRomToLibrary: Starting download for ID %d, Size: %d, Files: %d", id, game.FileSize, len(targets))
+	client := s.romm.GetClient()
+	hashers := make([]*romHasher, len(targets))
+	var done int64
+	for i := range targets {
+		t := &targets[i]
+		fetch := func(offset int64, ifRange string) (*romm.RomDownload, error) {
+			if t.file == nil {
+				return client.DownloadFileRange(ctx, &game, offset, ifRange)
+			}
+			return client.DownloadRomFile(ctx, &game, t.file, offset, ifRange)
+		}
+		if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+			return fmt.Errorf("failed to create destination directory: %w", err)
+		}
+		hashers[i] = t.hasher()
+		for attempt := 1; ; attempt++ {
+			err = s.downloadPart(fetch, t.path+partSuffix, done, pw, hashers[i])
+			if err == nil || attempt >= downloadAttempts || !resumableDownloadError(ctx, err) {
+				break
+			}
+			s.ui.LogErrorf("DownloadRomToLibrary: Download of ID %d interrupted, resuming: %v", id, err)
+		}
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(t.path + partSuffix); err == nil {
+			done += info.Size()
+		}
 	}
-	downloadSuccess = true
 
-	// Explicitly close the file handle so Windows allows extraction and deletion
-	_ = out.Close()
+	// Only move files into place once all of them are complete and intact, so a
+	// game is never half there.
+	for i := range targets {
+		partPath := targets[i].path + partSuffix
+		if err := hashers[i].verify(); err != nil {
+			// Keep the file to inspect, but start the next download over.
+			_ = os.Remove(partPath + partInfoSuffix)
+			s.ui.LogErrorf("DownloadRomToLibrary: Download of ID %d is corrupt, kept at %s: %v", id, partPath, err)
+			return fmt.Errorf("failed to verify %s: %w", filepath.Base(targets[i].path), err)
+		}
+	}
+	for i := range targets {
+		partPath := targets[i].path + partSuffix
+		if err := os.Rename(partPath, targets[i].path); err != nil {
+			return fmt.Errorf("failed to move download into place: %w", err)
+		}
+		_ = os.Remove(partPath + partInfoSuffix)
+	}
 
-	return s.postDownloadProcessing(id, &game, destPath, destDir)
+	return s.postDownloadProcessing(id, &game, targets[0].path, destDir)
 }
 
 func (s *Service) postDownloadProcessing(id uint, game *types.Game, destPath, destDir string) error {
+	// Folder ROMs are downloaded as their individual files, never as an archive
+	if singleDownload(game) {
+		s.extractDownload(id, game, destPath, destDir)
+	}
+
+	if playlist, err := writePlaylist(game, destDir); err != nil {
+		s.ui.LogErrorf("DownloadRomToLibrary: Failed to write disc playlist: %v", err)
+	} else if playlist != "" {
+		s.ui.LogInfof("DownloadRomToLibrary: Wrote disc playlist %s", playlist)
+	}
+
+	// Save metadata
+	if err := s.SaveMetadata(game); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	s.ui.EventsEmit("library-status", map[string]interface{}{"game_id": id, "status": "downloaded"})
+	return nil
+}
+
+// extractDownload extracts .cue/.bin, PS2 or GameCube files from a downloaded
+// archive and removes the archive when it did.
+func (s *Service) extractDownload(id uint, game *types.Game, destPath, destDir string) {
 	// Archive check: Extract .cue/.bin or GameCube files if present
 	s.ui.EventsEmit("library-status", map[string]interface{}{"game_id": id, "status": "extracting"})
 
@@ -180,14 +224,6 @@ func (s *Service) postDownloadProcessing(id uint, game *types.Game, destPath, de
 			s.ui.LogErrorf("DownloadRomToLibrary: Failed to remove archive after extraction: %v", err)
 		}
 	}
-
-	// Save metadata
-	if err := s.SaveMetadata(game); err != nil {
-		return fmt.Errorf("failed to save metadata: %w", err)
-	}
-
-	s.ui.EventsEmit("library-status", map[string]interface{}{"game_id": id, "status": "downloaded"})
-	return nil
 }
 
 // SaveMetadata saves the game metadata to a local JSON file.
@@ -327,12 +363,16 @@ func (s *Service) GetRomDownloadStatus(id uint) (bool, error) {
 	return false, nil
 }
 
-// findRomPath looks for a valid ROM file in the given directory.
+// findRomPath looks for a valid ROM file in the given directory, preferring a disc
+// playlist.
 func (s *Service) findRomPath(romDir string) string {
 	files, err := os.ReadDir(romDir)
 	if err != nil {
 		return ""
 	}
+	if p := FindPlaylist(romDir, files); p != "" {
+		return p
+	}
 
 	for _, file := range files {
 		if file.IsDir() {
@@ -0,0 +1,116 @@
+package library
+
+import (
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const playlistExt = ".m3u"
+
+// discExts are the disc image formats listed in a generated playlist. Track files
+// such as .bin, which a .cue or .gdi sheet refers to, are not discs themselves.
+var discExts = map[string]bool{
+	".cue": true,
+	".gdi": true,
+	".chd": true,
+	".iso": true,
+	".cdi": true,
+	".pbp": true,
+	".ccd": true,
+	".mds": true,
+}
+
+// FindPlaylist returns the disc playlist among the files of a ROM directory, if
+// there is one.
+func FindPlaylist(romDir string, files []os.DirEntry) string {
+	for _, file := range files {
+		if !file.IsDir() && !strings.HasPrefix(file.Name(), ".") && strings.ToLower(filepath.Ext(file.Name())) == playlistExt {
+			return filepath.Join(romDir, file.Name())
+		}
+	}
+	return ""
+}
+
+// FirstDisc resolves a disc playlist to the image of its first disc, so its header
+// can be read. Other paths are returned as they are, and "" when the playlist
+// cannot be read or lists no disc.
+func FirstDisc(path string) string {
+	if strings.ToLower(filepath.Ext(path)) != playlistExt {
+		return path
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		disc := filepath.FromSlash(line)
+		if !filepath.IsAbs(disc) {
+			disc = filepath.Join(filepath.Dir(path), disc)
+		}
+		return disc
+	}
+	return ""
+}
+
+// writePlaylist writes an .m3u playlist listing the discs of a multi-disc game, so
+// RetroArch can swap discs and keeps one set of saves for all of them. It returns
+// the path of the playlist, or "" when the game has a single disc or already comes
+// with a playlist.
+func writePlaylist(game *types.Game, romDir string) (string, error) {
+	files, err := os.ReadDir(romDir)
+	if err != nil {
+		return "", err
+	}
+	if FindPlaylist(romDir, files) != "" {
+		return "", nil
+	}
+
+	var discs []string
+	err = filepath.WalkDir(romDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == romDir {
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || d.Name() == constants.DirSaves || d.Name() == constants.DirStates {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(d.Name(), ".") && discExts[strings.ToLower(filepath.Ext(d.Name()))] {
+			rel, err := filepath.Rel(romDir, path)
+			if err != nil {
+				return err
+			}
+			discs = append(discs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil || len(discs) < 2 {
+		return "", err
+	}
+	sort.Slice(discs, func(i, j int) bool { return strings.ToLower(discs[i]) < strings.ToLower(discs[j]) })
+
+	// A folder ROM is named after its folder, whose name may contain dots; a
+	// single download is named after the archive it was extracted from.
+	name := filepath.Base(game.FullPath)
+	if singleDownload(game) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	path := filepath.Join(romDir, name+playlistExt)
+	if err := os.WriteFile(path, []byte(strings.Join(discs, "\n")+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
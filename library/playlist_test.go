@@ -0,0 +1,130 @@
+package library
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"go-romm-sync/types"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDownloadRomToLibrary_MultiFile(t *testing.T) {
+	files := []types.RomFile{
+		{ID: 11, FileName: "Game (Disc 2).cue", FullPath: "psx/Game/Game (Disc 2).cue"},
+		{ID: 12, FileName: "Game (Disc 2).bin", FullPath: "psx/Game/Game (Disc 2).bin"},
+		{ID: 13, FileName: "Game (Disc 1).cue", FullPath: "psx/Game/Game (Disc 1).cue"},
+		{ID: 14, FileName: "Game (Disc 1).bin", FullPath: "psx/Game/Game (Disc 1).bin"},
+		{ID: 15, FileName: "Patch.ppf", FullPath: "psx/Game/update/Patch.ppf", Category: "update"},
+	}
+	for i := range files {
+		files[i].FileSize = int64(len(files[i].FileName))
+		files[i].SHA1Hash = hashHex(sha1.New(), files[i].FileName)
+	}
+	game, _ := json.Marshal(types.Game{ID: 1, FullPath: "psx/Game", Files: files, HasMultipleFiles: true})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/content/") {
+			_, _ = w.Write(game)
+			return
+		}
+		id, _ := strconv.Atoi(r.URL.Query().Get("file_ids"))
+		for _, f := range files {
+			if f.ID == uint(id) {
+				_, _ = w.Write([]byte(f.FileName))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	s, _ := newDownloadService(t, server.URL)
+	if err := s.DownloadRomToLibrary(context.Background(), 1); err != nil {
+		t.Fatalf("DownloadRomToLibrary failed: %v", err)
+	}
+
+	romDir := filepath.Join(s.config.GetConfig().LibraryPath, "psx", "1")
+	for _, rel := range []string{"Game (Disc 1).cue", "Game (Disc 2).bin", "update/Patch.ppf"} {
+		if data, _ := os.ReadFile(filepath.Join(romDir, rel)); string(data) != filepath.Base(rel) {
+			t.Errorf("Expected %s to be downloaded, got %q", rel, data)
+		}
+	}
+	playlist := filepath.Join(romDir, "Game.m3u")
+	if data, _ := os.ReadFile(playlist); string(data) != "Game (Disc 1).cue\nGame (Disc 2).cue\n" {
+		t.Errorf("Unexpected playlist %q", data)
+	}
+	if got := s.FindRomPath(romDir); got != playlist {
+		t.Errorf("Expected the playlist to be the ROM, got %s", got)
+	}
+	if checks, _ := s.VerifyLibrary(); len(checks) != 1 || checks[0].Status != VerifyOK {
+		t.Errorf("Expected the downloaded files to verify, got %+v", checks)
+	}
+}
+
+func TestWritePlaylist(t *testing.T) {
+	tests := []struct {
+		name  string
+		game  types.Game
+		files []string
+		want  string // playlist written, or "" for none
+	}{
+		{"single disc", types.Game{FullPath: "psx/Game.zip"}, []string{"Game.cue", "Game.bin"}, ""},
+		{"extracted discs", types.Game{FullPath: "psx/Game Vol. 2.zip"}, []string{"B.chd", "a.chd", "saves/x/c.chd"}, "Game Vol. 2.m3u"},
+		{"folder with dots", types.Game{FullPath: "psx/Game Vol. 2", Files: make([]types.RomFile, 2), HasMultipleFiles: true}, []string{"1.iso", "2.iso"}, "Game Vol. 2.m3u"},
+		{"existing playlist", types.Game{FullPath: "psx/Game.zip"}, []string{"1.iso", "2.iso", "Own.m3u"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			romDir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(romDir, filepath.FromSlash(f))
+				_ = os.MkdirAll(filepath.Dir(path), 0o755)
+				_ = os.WriteFile(path, nil, 0o644)
+			}
+			got, err := writePlaylist(&tt.game, romDir)
+			if err != nil {
+				t.Fatalf("writePlaylist failed: %v", err)
+			}
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("Expected no playlist, got %s", got)
+				}
+				return
+			}
+			if got != filepath.Join(romDir, tt.want) {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFirstDisc(t *testing.T) {
+	romDir := t.TempDir()
+	playlist := filepath.Join(romDir, "Game.m3u")
+	if err := os.WriteFile(playlist, []byte("#EXTM3U\r\n\r\ndiscs/Game (Disc 1).iso\r\nGame (Disc 2).iso\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(romDir, "Empty.m3u")
+	if err := os.WriteFile(empty, []byte("# no discs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct{ path, want string }{
+		"playlist":       {playlist, filepath.Join(romDir, "discs", "Game (Disc 1).iso")},
+		"empty playlist": {empty, ""},
+		"missing":        {filepath.Join(romDir, "Missing.m3u"), ""},
+		"disc image":     {filepath.Join(romDir, "Game.iso"), filepath.Join(romDir, "Game.iso")},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := FirstDisc(tt.path); got != tt.want {
+				t.Errorf("FirstDisc(%s) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package library
+
+import (
+	"crypto/md5"  //nolint:gosec // RomM's hash, not used for security
+	"crypto/sha1" //nolint:gosec // RomM's hash, not used for security
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ErrHashMismatch is returned when a ROM does not match the hashes RomM reported.
+var ErrHashMismatch = errors.New("file does not match RomM's hash")
+
+// Results of verifying a downloaded ROM.
+const (
+	VerifyOK       = "ok"
+	VerifyMismatch = "mismatch" // corrupted or modified since it was downloaded
+	VerifyMissing  = "missing"  // not found or not readable
+	VerifySkipped  = "skipped"  // no hashes to compare with, an archive, or the download was extracted
+)
+
+type romHash struct {
+	name string
+	want string
+	hash hash.Hash
+}
+
+// romHasher computes the hashes RomM reported for a ROM, skipping those it did not
+// report, from the content written to it.
+type romHasher struct {
+	hashes []romHash
+}
+
+func newRomHasher(crcHash, md5Hash, sha1Hash string) *romHasher {
+	h := &romHasher{}
+	for _, c := range []struct {
+		name, want string
+		new        func() hash.Hash
+	}{
+		{"CRC32", crcHash, func() hash.Hash { return crc32.NewIEEE() }},
+		{"MD5", md5Hash, md5.New},
+		{"SHA1", sha1Hash, sha1.New},
+	} {
+		if c.want != "" {
+			h.hashes = append(h.hashes, romHash{name: c.name, want: c.want, hash: c.new()})
+		}
+	}
+	return h
+}
+
+func (h *romHasher) Write(p []byte) (int, error) {
+	for _, rh := range h.hashes {
+		rh.hash.Write(p)
+	}
+	return len(p), nil
+}
+
+// Reset discards everything written so far.
+func (h *romHasher) Reset() {
+	for _, rh := range h.hashes {
+		rh.hash.Reset()
+	}
+}
+
+// hashFile writes the first n bytes of the file at path to the hasher, or all of
+// it when n is negative.
+func (h *romHasher) hashFile(path string, n int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	if n < 0 {
+		n = math.MaxInt64
+	}
+	if _, err := io.Copy(h, io.LimitReader(f, n)); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return nil
+}
+
+// verify compares the hashes of the content written with the ones RomM reported.
+func (h *romHasher) verify() error {
+	for _, rh := range h.hashes {
+		if got := hex.EncodeToString(rh.hash.Sum(nil)); !strings.EqualFold(got, rh.want) {
+			return fmt.Errorf("%w: %s is %s, expected %s", ErrHashMismatch, rh.name, got, rh.want)
+		}
+	}
+	return nil
+}
+
+// RomCheck is the result of verifying one downloaded ROM.
+type RomCheck struct {
+	GameID uint   `json:"game_id"`
+	Title  string `json:"title"`
+	Path   string `json:"path"`   // the file checked, or the folder of a multi-file ROM
+	Status string `json:"status"` // VerifyOK, VerifyMismatch, VerifyMissing or VerifySkipped
+	Detail string `json:"detail,omitempty"`
+}
+
+// LibraryVerifyProgress is emitted as constants.EventLibraryVerifyProgress after each ROM.
+type LibraryVerifyProgress struct {
+	RomCheck
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// VerifyLibrary rehashes every downloaded ROM and compares it with the hashes RomM
+// reported when it was downloaded, which are kept in its metadata, so it works
+// while RomM is unreachable. Archives, which RomM hashes by their decompressed
+// content, and ROMs that were extracted after downloading are skipped.
+func (s *Service) VerifyLibrary() ([]RomCheck, error) {
+	games, _, err := s.GetLocalLibrary(math.MaxInt32, 0, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local library: %w", err)
+	}
+
+	checks := make([]RomCheck, 0, len(games))
+	for i := range games {
+		check := s.verifyRom(&games[i])
+		switch check.Status {
+		case VerifyMismatch, VerifyMissing:
+			s.ui.LogErrorf("VerifyLibrary: %s (%d) is %s: %s", check.Title, check.GameID, check.Status, check.Detail)
+		}
+		checks = append(checks, check)
+		s.ui.EventsEmit(constants.EventLibraryVerifyProgress, LibraryVerifyProgress{RomCheck: check, Completed: i + 1, Total: len(games)})
+	}
+	s.ui.LogInfof("VerifyLibrary: Verified %d ROMs", len(checks))
+	return checks, nil
+}
+
+func (s *Service) verifyRom(game *types.Game) RomCheck {
+	romDir := s.GetRomDir(game)
+	check := RomCheck{GameID: game.ID, Title: game.Title, Path: romDir}
+	targets, err := romTargets(game, romDir)
+	if err != nil {
+		check.Status, check.Detail = VerifyMissing, err.Error()
+		return check
+	}
+
+	verified, archives := 0, 0
+	for i := range targets {
+		t := &targets[i]
+		check.Path = t.path
+		if t.archived() {
+			archives++
+			continue
+		}
+		h := t.hasher()
+		if len(h.hashes) == 0 {
+			continue
+		}
+		if _, err := os.Stat(t.path); err != nil {
+			if t.file == nil {
+				if found := s.findRomPath(romDir); found != "" {
+					check.Path = found
+					check.Status, check.Detail = VerifySkipped, "extracted after download"
+					return check
+				}
+			}
+			check.Status, check.Detail = VerifyMissing, "ROM file not found"
+			return check
+		}
+		if err := h.hashFile(t.path, -1); err != nil {
+			check.Status, check.Detail = VerifyMissing, err.Error()
+			return check
+		}
+		if err := h.verify(); err != nil {
+			check.Status, check.Detail = VerifyMismatch, err.Error()
+			return check
+		}
+		verified++
+	}
+	if verified == 0 {
+		check.Status, check.Detail = VerifySkipped, "RomM reported no hashes"
+		if archives > 0 {
+			check.Detail = "RomM hashes archives by their content"
+		}
+		return check
+	}
+	if len(targets) > 1 {
+		check.Path = romDir
+	}
+	check.Status = VerifyOK
+	return check
+}
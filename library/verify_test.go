@@ -0,0 +1,56 @@
+package library
+
+import (
+	"crypto/sha1"
+	"go-romm-sync/config"
+	"go-romm-sync/rommsrv"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyLibrary(t *testing.T) {
+	tempDir := t.TempDir()
+	cm := config.NewConfigManager()
+	cm.ConfigPath = filepath.Join(tempDir, "config.json")
+	cm.Config = &types.AppConfig{LibraryPath: tempDir}
+	s := New(cm, rommsrv.New(mockRommConfig{}), &MockUIProvider{})
+
+	sum := hashHex(sha1.New(), romContent)
+	games := []struct {
+		game types.Game
+		file string // name of the ROM file written, if any
+		data string
+		want string
+	}{
+		{types.Game{ID: 1, FullPath: "SNES/Good.sfc", SHA1Hash: sum}, "Good.sfc", romContent, VerifyOK},
+		{types.Game{ID: 2, FullPath: "SNES/Modified.sfc", SHA1Hash: sum}, "Modified.sfc", "modified", VerifyMismatch},
+		{types.Game{ID: 3, FullPath: "SNES/Gone.sfc", SHA1Hash: sum}, "", "", VerifyMissing},
+		{types.Game{ID: 4, FullPath: "PSX/Disc.zip", SHA1Hash: sum}, "Disc.cue", "cue", VerifySkipped},
+		{types.Game{ID: 5, FullPath: "SNES/Old.sfc"}, "Old.sfc", romContent, VerifySkipped},
+	}
+	want := make(map[uint]string)
+	for _, g := range games {
+		if err := s.SaveMetadata(&g.game); err != nil {
+			t.Fatal(err)
+		}
+		if g.file != "" {
+			_ = os.WriteFile(filepath.Join(s.GetRomDir(&g.game), g.file), []byte(g.data), 0o644)
+		}
+		want[g.game.ID] = g.want
+	}
+
+	checks, err := s.VerifyLibrary()
+	if err != nil {
+		t.Fatalf("VerifyLibrary failed: %v", err)
+	}
+	if len(checks) != len(games) {
+		t.Fatalf("Expected %d results, got %d", len(games), len(checks))
+	}
+	for _, c := range checks {
+		if c.Status != want[c.GameID] {
+			t.Errorf("Expected %s for game %d, got %s (%s)", want[c.GameID], c.GameID, c.Status, c.Detail)
+		}
+	}
+}
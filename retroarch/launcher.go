@@ -25,7 +25,9 @@ type UIProvider interface {
 
 // Launch launches RetroArch for the given ROM path and selected executable.
 // coreOverride, when non-empty, bypasses the CoreMap lookup and forces that specific core.
-func Launch(ui UIProvider, exePath, romPath, cheevosUser, cheevosPass, coreOverride, platform, customBiosDir string) error {
+// onExit, when non-nil, is called once the RetroArch process has returned, after the
+// game-exited event is emitted and the window is restored.
+func Launch(ui UIProvider, exePath, romPath, cheevosUser, cheevosPass, coreOverride, platform, customBiosDir string, onExit func()) error {
 	baseDir, resolvedExePath, err := resolveRetroArchPaths(exePath)
 	if err != nil {
 		return err
@@ -68,14 +70,14 @@ func Launch(ui UIProvider, exePath, romPath, cheevosUser, cheevosPass, coreOverr
 
 	appendConfigPath := prepareLaunchEnv(ui, baseDir, romBaseDir, platform, customBiosDir, cheevosUser, cheevosPass)
 
-	runRetroArch(ui, exePath, baseDir, corePath, romPath, appendConfigPath, tempRomPath)
+	runRetroArch(ui, exePath, baseDir, corePath, romPath, appendConfigPath, tempRomPath, onExit)
 
 	return nil
 }
 
 // runRetroArch executes the RetroArch process in a separate goroutine and handles
 // the lifecycle events (started, exited, cleanup).
-func runRetroArch(ui UIProvider, exePath, baseDir, corePath, romPath, appendConfigPath, tempRomPath string) {
+func runRetroArch(ui UIProvider, exePath, baseDir, corePath, romPath, appendConfigPath, tempRomPath string, onExit func()) {
 	args := []string{"-L", corePath, "-f", "-v"}
 	if appendConfigPath != "" {
 		args = append(args, "--appendconfig", appendConfigPath)
@@ -98,6 +100,11 @@ func runRetroArch(ui UIProvider, exePath, baseDir, corePath, romPath, appendConf
 				ui.WindowShow()
 				ui.WindowUnminimise()
 			}
+			// The UI is back before the session upload, which may be slow or
+			// wait for an unreachable server.
+			if onExit != nil {
+				onExit()
+			}
 		}()
 
 		ui.EventsEmit(constants.EventGameStarted, nil)
@@ -80,7 +80,7 @@ func TestLaunch_Errors(t *testing.T) {
 	ui := &MockUI{}
 
 	// Test missing exe
-	err := Launch(ui, "/non/existent/retroarch", "rom.sfc", "", "", "", "", "")
+	err := Launch(ui, "/non/existent/retroarch", "rom.sfc", "", "", "", "", "", nil)
 	if err == nil {
 		t.Error("Expected error for non-existent executable")
 	}
@@ -91,7 +91,7 @@ func TestLaunch_Errors(t *testing.T) {
 	exePath := filepath.Join(tempDir, "retroarch")
 	os.WriteFile(exePath, []byte("fake"), 0o755)
 
-	err = Launch(ui, exePath, "rom.unknown", "", "", "", "", "")
+	err = Launch(ui, exePath, "rom.unknown", "", "", "", "", "", nil)
 	if err == nil {
 		t.Error("Expected error for unknown extension")
 	}
@@ -114,7 +114,7 @@ func TestLaunch_Zip(t *testing.T) {
 	w.Close()
 	os.WriteFile(zipPath, buf.Bytes(), 0o644)
 
-	err := Launch(ui, exePath, zipPath, "", "", "", "", "")
+	err := Launch(ui, exePath, zipPath, "", "", "", "", "", nil)
 	// It might error because coresDir/cores/... missing, which is fine, we just want to see it gets there.
 	if err != nil && !strings.Contains(err.Error(), "emulator core not found") {
 		t.Errorf("Unexpected error during zip launch: %v", err)
@@ -132,7 +132,7 @@ func TestLaunch_Pico8(t *testing.T) {
 	p8Path := filepath.Join(tempDir, "game.png")
 	os.WriteFile(p8Path, []byte("png data"), 0o644)
 
-	err := Launch(ui, exePath, p8Path, "", "", "", "", "")
+	err := Launch(ui, exePath, p8Path, "", "", "", "", "", nil)
 	if err != nil && !strings.Contains(err.Error(), "emulator core not found") {
 		t.Errorf("Unexpected error during pico8 launch: %v", err)
 	}
@@ -159,7 +159,7 @@ func TestLaunch_CoreNotSupported(t *testing.T) {
 
 	// This should trigger DownloadCore, which will return 404,
 	// and Launch should catch it and emit "Core Not Supported".
-	err := Launch(ui, exePath, "game.sfc", "", "", "", "", "")
+	err := Launch(ui, exePath, "game.sfc", "", "", "", "", "", nil)
 	if err == nil {
 		t.Fatal("Expected error from Launch")
 	}
@@ -187,7 +187,7 @@ func TestLaunch_ExeDir(t *testing.T) {
 	exePath := filepath.Join(tempDir, exeName)
 	os.WriteFile(exePath, []byte("fake"), 0o755)
 
-	err := Launch(ui, tempDir, "rom.sfc", "", "", "", "", "")
+	err := Launch(ui, tempDir, "rom.sfc", "", "", "", "", "", nil)
 	if err != nil && !strings.Contains(err.Error(), "emulator core not found") {
 		t.Errorf("Unexpected error during exe dir launch: %v", err)
 	}
@@ -204,7 +204,7 @@ func TestLaunch_AppBundle(t *testing.T) {
 	appPath := filepath.Join(tempDir, "RetroArch.app")
 	os.MkdirAll(appPath, 0o755)
 
-	err := Launch(ui, appPath, "rom.sfc", "", "", "", "", "")
+	err := Launch(ui, appPath, "rom.sfc", "", "", "", "", "", nil)
 	// Should at least pass the directory check and fail on core/binary lookup
 	if err != nil && strings.Contains(err.Error(), "retroarch executable not found in directory") {
 		t.Errorf("Failed to resolve .app bundle: %v", err)
@@ -223,7 +223,7 @@ func TestLaunch_CoreOverride(t *testing.T) {
 	os.WriteFile(romPath, []byte("rom data"), 0o644)
 
 	// Should fail at core download/find, not at the override logic
-	err := Launch(ui, exePath, romPath, "", "", "my_custom_core_libretro", "", "")
+	err := Launch(ui, exePath, romPath, "", "", "my_custom_core_libretro", "", "", nil)
 	if err != nil && !strings.Contains(err.Error(), "core not supported") && !strings.Contains(err.Error(), "emulator core not found") {
 		t.Errorf("Expected core-not-found or not-supported error with override, got: %v", err)
 	}
@@ -243,7 +243,7 @@ func TestLaunch_PathTraversal(t *testing.T) {
 	// Attempt a path traversal. It should be sanitized to "evil.dll" (or .so/.dylib)
 	// and fail because it's not in the cores directory, rather than attempting to load
 	// a library from a completely different path.
-	err := Launch(ui, exePath, romPath, "", "", "../../evil", "", "")
+	err := Launch(ui, exePath, romPath, "", "", "../../evil", "", "", nil)
 	if err != nil && !strings.Contains(err.Error(), "core not supported") && !strings.Contains(err.Error(), "emulator core not found") {
 		t.Errorf("Expected core-not-found or not-supported error for sanitized path, got: %v", err)
 	}
@@ -271,7 +271,7 @@ func TestLaunch_Events(t *testing.T) {
 	}
 
 	// Launch should return nil or a core-not-found error, but should trigger the start event regardless if it reaches that point.
-	err = Launch(ui, exePath, romPath, "", "", "", "", "")
+	err = Launch(ui, exePath, romPath, "", "", "", "", "", nil)
 	if err != nil && !strings.Contains(err.Error(), "emulator core not found") {
 		// Only log an actual systemic error, core-not-found is expected in this mock environment
 		t.Logf("Launch returned expected core error: %v", err)
@@ -298,3 +298,32 @@ Loop:
 		t.Log("Warning: EventGameStarted not detected in time via channel.")
 	}
 }
+
+func TestRunRetroArch_OnExitAfterExitedEvent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on windows due to shell script usage")
+	}
+	ui := &MockUI{EventChan: make(chan string, 10)}
+	tempDir := t.TempDir()
+
+	exePath := filepath.Join(tempDir, "retroarch")
+	if err := os.WriteFile(exePath, []byte("#!/bin/sh\nexit 0"), 0o755); err != nil {
+		t.Fatalf("failed to write mock exe: %v", err)
+	}
+
+	exitedAtCallback := -1
+	done := make(chan struct{})
+	runRetroArch(ui, exePath, tempDir, "core", "rom.sfc", "", "", func() {
+		exitedAtCallback = ui.GetEventCount(constants.EventGameExited)
+		close(done)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("onExit was not called after RetroArch returned")
+	}
+	if exitedAtCallback != 1 {
+		t.Errorf("Expected onExit to run after %s was emitted, got %d prior events", constants.EventGameExited, exitedAtCallback)
+	}
+}
@@ -52,18 +52,19 @@ func getCoreExt() string {
 	}
 }
 
-// ClearCheevosToken finds the RetroArch config file and clears the cheevos_token setting.
-// This ensures that when credentials are changed, RetroArch will re-authenticate.
-func ClearCheevosToken(exePath string) error {
-	var configPaths []string
+// configPaths returns the locations retroarch.cfg may be at, most specific first:
+// next to the executable, or in the provided directory, then the standard
+// OS-specific location.
+func configPaths(exePath string) []string {
+	var paths []string
 
 	// 1. Path based on exe directory or provided directory
 	if exePath != "" {
 		if info, err := os.Stat(exePath); err == nil {
 			if info.IsDir() {
-				configPaths = append(configPaths, filepath.Join(exePath, "retroarch.cfg"))
+				paths = append(paths, filepath.Join(exePath, "retroarch.cfg"))
 			} else {
-				configPaths = append(configPaths, filepath.Join(filepath.Dir(exePath), "retroarch.cfg"))
+				paths = append(paths, filepath.Join(filepath.Dir(exePath), "retroarch.cfg"))
 			}
 		}
 	}
@@ -72,17 +73,22 @@ func ClearCheevosToken(exePath string) error {
 	if home, err := os.UserHomeDir(); err == nil {
 		switch runtime.GOOS {
 		case constants.OSLinux:
-			configPaths = append(configPaths, filepath.Join(home, ".config", "retroarch", "retroarch.cfg"))
+			paths = append(paths, filepath.Join(home, ".config", "retroarch", "retroarch.cfg"))
 		case constants.OSDarwin:
-			configPaths = append(configPaths, filepath.Join(home, "Library", "Application Support", "RetroArch", "config", "retroarch.cfg"))
+			paths = append(paths, filepath.Join(home, "Library", "Application Support", "RetroArch", "config", "retroarch.cfg"))
 		}
 	}
+	return paths
+}
 
+// ClearCheevosToken finds the RetroArch config file and clears the cheevos_token setting.
+// This ensures that when credentials are changed, RetroArch will re-authenticate.
+func ClearCheevosToken(exePath string) error {
 	// Matches the line starting with cheevos_token = (case-insensitive, allowing leading whitespace)
 	re := regexp.MustCompile(`(?mi)^\s*cheevos_token\s*=\s*.*`)
 
 	// Try to find and clear the token in each potential config path
-	for _, path := range configPaths {
+	for _, path := range configPaths(exePath) {
 		if _, err := os.Stat(path); err == nil {
 			content, err := os.ReadFile(path)
 			if err != nil {
@@ -102,6 +108,50 @@ func ClearCheevosToken(exePath string) error {
 	return nil
 }
 
+// FindSaveDirs reads savefile_directory and savestate_directory from the first
+// retroarch.cfg found the same way as ClearCheevosToken. Unset directories resolve
+// to the "saves" and "states" folders next to the config file.
+func FindSaveDirs(exePath string) (savesDir, statesDir string, err error) {
+	for _, path := range configPaths(exePath) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		cfgDir := filepath.Dir(path)
+		savesDir = resolveConfigDir(configValue(string(content), "savefile_directory"), cfgDir, "saves")
+		statesDir = resolveConfigDir(configValue(string(content), "savestate_directory"), cfgDir, "states")
+		return savesDir, statesDir, nil
+	}
+	return "", "", fmt.Errorf("no retroarch.cfg found")
+}
+
+// configValue returns the value of a key in retroarch.cfg, without quotes.
+func configValue(content, key string) string {
+	re := regexp.MustCompile(`(?mi)^\s*` + regexp.QuoteMeta(key) + `\s*=\s*"?([^"\r\n]*)"?`)
+	m := re.FindStringSubmatch(content)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
+
+// resolveConfigDir expands a directory setting of retroarch.cfg. "default" and
+// empty values fall back to a folder next to the config file, a leading ":" refers
+// to the RetroArch directory and a leading "~" to the home directory.
+func resolveConfigDir(value, cfgDir, fallback string) string {
+	switch {
+	case value == "" || value == "default":
+		return filepath.Join(cfgDir, fallback)
+	case strings.HasPrefix(value, ":"):
+		return filepath.Join(cfgDir, filepath.FromSlash(strings.TrimLeft(value[1:], `/\`)))
+	case strings.HasPrefix(value, "~"):
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, filepath.FromSlash(strings.TrimLeft(value[1:], `/\`)))
+		}
+	}
+	return filepath.Clean(value)
+}
+
 // isAppleSilicon returns true if the current host is running on Apple Silicon hardware,
 // regardless of whether the current process is running via Rosetta.
 func isAppleSilicon() bool {
@@ -84,3 +84,32 @@ func TestClearCheevosToken(t *testing.T) {
 		t.Errorf("Expected cheevos_token to be cleared, got:\n%s", string(newContent))
 	}
 }
+
+func TestFindSaveDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	cfgPath := filepath.Join(tempDir, "retroarch.cfg")
+	content := "savefile_directory = \":/my saves\"\nsavestate_directory = \"default\"\n"
+	if err := os.WriteFile(cfgPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	savesDir, statesDir, err := FindSaveDirs(tempDir)
+	if err != nil {
+		t.Fatalf("FindSaveDirs failed: %v", err)
+	}
+	if savesDir != filepath.Join(tempDir, "my saves") {
+		t.Errorf("Unexpected saves dir %q", savesDir)
+	}
+	if statesDir != filepath.Join(tempDir, "states") {
+		t.Errorf("Unexpected states dir %q", statesDir)
+	}
+
+	absolute := filepath.Join(tempDir, "abs")
+	content = "savefile_directory = \"" + filepath.ToSlash(absolute) + "\"\n"
+	if err := os.WriteFile(cfgPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if savesDir, _, _ := FindSaveDirs(tempDir); savesDir != absolute {
+		t.Errorf("Expected %q, got %q", absolute, savesDir)
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,9 +29,21 @@ const (
 // Client handles communication with the RomM API
 type Client struct {
 	BaseURL    string
-	Token      string
 	APIClient  *http.Client // For standard API calls (60s timeout)
-	FileClient *http.Client // For large file downloads (2h timeout)
+	FileClient *http.Client // For large file downloads and uploads (2h timeout)
+
+	MaxRetries int           // Retries of failed GET requests; 0 disables retrying
+	RetryDelay time.Duration // Backoff before the first retry
+
+	// Reauth, when set, logs in again after a request is rejected with 401 and
+	// returns the new token; the request is then replayed once with it.
+	Reauth func() (string, error)
+
+	// mu guards the token and the state of a running re-login; see reauthenticate.
+	mu             sync.Mutex
+	token          string
+	reauthDone     chan struct{} // closed when the running re-login finishes; nil when none runs
+	reauthRejected string        // token the running re-login replaces
 }
 
 // ponytail: two http.Clients (60s vs 2h) — consider merging into one client with per-call timeout.
@@ -43,6 +57,8 @@ func NewClient(baseURL string) *Client {
 		FileClient: &http.Client{
 			Timeout: 2 * time.Hour,
 		},
+		MaxRetries: DefaultMaxRetries,
+		RetryDelay: DefaultRetryDelay,
 	}
 }
 
@@ -60,15 +76,14 @@ func (c *Client) Login(username, password string) (string, error) {
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	resp, err := c.do(c.APIClient, req, "failed to perform login request") //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return "", fmt.Errorf("failed to perform login request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return "", fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(body))
+		return "", c.statusError(resp, "login failed")
 	}
 
 	var result struct {
@@ -80,13 +95,28 @@ func (c *Client) Login(username, password string) (string, error) {
 		return "", fmt.Errorf("failed to decode login response: %w", err)
 	}
 
-	c.Token = result.AccessToken
-	return c.Token, nil
+	c.SetToken(result.AccessToken)
+	return result.AccessToken, nil
+}
+
+// Token returns the token sent with requests.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// SetToken replaces the token sent with requests. It is safe to call while other
+// requests are running.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
 }
 
 // CreateClientToken creates a persistent client token
 func (c *Client) CreateClientToken(name string, scopes []string) (string, error) {
-	if c.Token == "" {
+	if c.Token() == "" {
 		return "", fmt.Errorf("not authenticated to create client token")
 	}
 
@@ -110,17 +140,16 @@ func (c *Client) CreateClientToken(name string, scopes []string) (string, error)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	resp, err := c.do(c.APIClient, req, "failed to perform token request") //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return "", fmt.Errorf("failed to perform token request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return "", fmt.Errorf("token creation failed with status %d: %s", resp.StatusCode, string(respBody))
+		return "", c.statusError(resp, "token creation failed")
 	}
 
 	var result struct {
@@ -142,8 +171,8 @@ func (c *Client) CreateClientToken(name string, scopes []string) (string, error)
 
 // GetLibrary fetches the list of games (ROMs) from the library
 func (c *Client) GetLibrary(limit, offset, platformID int, search string) ([]types.Game, int, error) {
-	if c.Token == "" {
-		return nil, 0, fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, 0, errNotAuthenticated
 	}
 
 	u, err := c.buildLibraryURL(limit, offset, platformID, search)
@@ -205,8 +234,8 @@ func validateCoverURL(targetURL string) error {
 
 // DownloadCover fetches the cover image from the provided URL
 func (c *Client) DownloadCover(coverURL string) ([]byte, error) {
-	if c.Token == "" {
-		return nil, fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, errNotAuthenticated
 	}
 
 	// Handles full URL or relative path
@@ -227,25 +256,25 @@ func (c *Client) DownloadCover(coverURL string) ([]byte, error) {
 
 	// Only send authorization if it's an internal RomM request
 	if c.shouldSendToken(targetURL) {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+		req.Header.Set("Authorization", "Bearer "+c.Token())
 	}
 
-	resp, err := c.FileClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	resp, err := c.do(c.FileClient, req, "failed to perform cover request") //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform cover request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("cover fetch failed with status %d", resp.StatusCode)
+		return nil, c.statusError(resp, "cover fetch failed")
 	}
 	return c.readAllWithLimit(resp.Body, MaxAssetSize)
 }
 
 // GetPlatforms fetches the list of platforms
 func (c *Client) GetPlatforms(limit, offset int) ([]types.Platform, int, error) {
-	if c.Token == "" {
-		return nil, 0, fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, 0, errNotAuthenticated
 	}
 
 	u, err := url.Parse(c.BaseURL + "/api/platforms")
@@ -273,8 +302,8 @@ func (c *Client) GetFirmware(platformID uint) ([]types.Firmware, error) {
 
 // GetRom fetches a single ROM by its ID
 func (c *Client) GetRom(id uint) (types.Game, error) {
-	if c.Token == "" {
-		return types.Game{}, fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return types.Game{}, errNotAuthenticated
 	}
 
 	urlStr := fmt.Sprintf("%s/api/roms/%d", c.BaseURL, id)
@@ -283,17 +312,16 @@ func (c *Client) GetRom(id uint) (types.Game, error) {
 		return types.Game{}, fmt.Errorf("failed to create ROM request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	resp, err := c.do(c.APIClient, req, "failed to perform ROM request") //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return types.Game{}, fmt.Errorf("failed to perform ROM request: %w", err)
+		return types.Game{}, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return types.Game{}, fmt.Errorf("ROM fetch failed with status %d: %s", resp.StatusCode, string(body))
+		return types.Game{}, c.statusError(resp, "ROM fetch failed")
 	}
 
 	var game types.Game
@@ -305,8 +333,30 @@ func (c *Client) GetRom(id uint) (types.Game, error) {
 
 // DownloadFile downloads a ROM file from RomM
 func (c *Client) DownloadFile(ctx context.Context, game *types.Game) (reader io.ReadCloser, filename string, err error) {
-	if c.Token == "" {
-		return nil, "", fmt.Errorf("not authenticated")
+	d, err := c.DownloadFileRange(ctx, game, 0, "")
+	if err != nil {
+		return nil, "", err
+	}
+	return d.Body, d.Filename, nil
+}
+
+// RomDownload is the response to a ROM download request.
+type RomDownload struct {
+	Body         io.ReadCloser
+	Filename     string
+	Offset       int64 // position of the first byte of Body in the file
+	Size         int64 // size of the whole file, -1 when unknown
+	ETag         string
+	LastModified string
+}
+
+// DownloadFileRange downloads a ROM file from RomM starting at offset. The range is
+// only honored while the file still matches ifRange, the ETag or Last-Modified
+// value of an earlier response; when it changed, or the server does not support
+// ranges, the whole file is returned with Offset 0.
+func (c *Client) DownloadFileRange(ctx context.Context, game *types.Game, offset int64, ifRange string) (*RomDownload, error) {
+	if c.Token() == "" {
+		return nil, errNotAuthenticated
 	}
 
 	// RomM download endpoint for a specific ROM: /api/roms/{id}/content/{fs_name}
@@ -317,25 +367,79 @@ func (c *Client) DownloadFile(ctx context.Context, game *types.Game) (reader io.
 	}
 
 	urlPath := fmt.Sprintf("%s/api/roms/%d/content/%s", c.BaseURL, game.ID, url.PathEscape(fsName))
-	req, err := http.NewRequestWithContext(ctx, "GET", urlPath, http.NoBody)
+	d, err := c.downloadContent(ctx, urlPath, offset, ifRange)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create download request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if game.FileSize <= 0 && d.Size > 0 {
+		game.FileSize = d.Size
+	}
+	if d.Filename == "" {
+		d.Filename = filepath.Base(game.FullPath)
+	}
+	return d, nil
+}
 
-	resp, err := c.FileClient.Do(req) //nolint:bodyclose // caller closes
+// DownloadRomFile downloads one file of a ROM that RomM stores as several files,
+// such as one disc of a multi-disc game, starting at offset like DownloadFileRange.
+func (c *Client) DownloadRomFile(ctx context.Context, game *types.Game, file *types.RomFile, offset int64, ifRange string) (*RomDownload, error) {
+	if c.Token() == "" {
+		return nil, errNotAuthenticated
+	}
+
+	// Selecting a single file of the ROM makes RomM serve that file instead of a zip of all of them
+	urlPath := fmt.Sprintf("%s/api/roms/%d/content/%s?file_ids=%d", c.BaseURL, game.ID, url.PathEscape(file.FileName), file.ID)
+	d, err := c.downloadContent(ctx, urlPath, offset, ifRange)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to perform download request: %w", err)
+		return nil, err
 	}
+	d.Filename = file.FileName
+	return d, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		_ = resp.Body.Close()
-		return nil, "", fmt.Errorf("download failed with status %d", resp.StatusCode)
+// downloadContent requests urlPath from offset on, as described for DownloadFileRange.
+func (c *Client) downloadContent(ctx context.Context, urlPath string, offset int64, ifRange string) (*RomDownload, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", urlPath, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.Token())
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if ifRange != "" {
+			req.Header.Set("If-Range", ifRange)
+		}
+	}
+
+	resp, err := c.do(c.FileClient, req, "failed to perform download request") //nolint:bodyclose // caller closes
+	if err != nil {
+		return nil, err
 	}
 
-	if game.FileSize <= 0 && resp.ContentLength > 0 {
-		game.FileSize = resp.ContentLength
+	d := &RomDownload{
+		Body:         resp.Body,
+		Size:         -1,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.ContentLength > 0 {
+		d.Size = resp.ContentLength
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("download returned an unexpected range %q", resp.Header.Get("Content-Range"))
+		}
+		d.Offset, d.Size = start, size
+	default:
+		err := c.statusError(resp, "download failed")
+		_ = resp.Body.Close()
+		return nil, err
 	}
 
 	// Double check Content-Disposition if the backend assigned an explicit download name
@@ -343,30 +447,68 @@ func (c *Client) DownloadFile(ctx context.Context, game *types.Game) (reader io.
 	if cd != "" && strings.Contains(cd, "filename=") {
 		parts := strings.Split(cd, "filename=")
 		if len(parts) > 1 {
-			filename = strings.Trim(parts[1], "\"")
+			d.Filename = strings.Trim(parts[1], "\"")
 		}
 	}
 
-	if filename == "" {
-		filename = filepath.Base(game.FullPath)
+	return d, nil
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 100-199/1000"
+// into the start of the range and the size of the whole file, which is -1 when
+// the server did not state it.
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
 	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if total == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
 
-	return resp.Body, filename, nil
+// UploadSave uploads a save file to RomM and returns the save record created by the server
+func (c *Client) UploadSave(romID uint, emulator, filename string, content []byte) (types.ServerSave, error) {
+	return c.UploadSaveFrom(romID, emulator, filename, bytes.NewReader(content))
+}
+
+// UploadState uploads a save state file to RomM and returns the state record created by the server
+func (c *Client) UploadState(romID uint, emulator, filename string, content []byte) (types.ServerState, error) {
+	return c.UploadStateFrom(romID, emulator, filename, bytes.NewReader(content))
 }
 
-// UploadSave uploads a save file to RomM
-func (c *Client) UploadSave(romID uint, emulator, filename string, content []byte) error {
-	return c.uploadAsset(romID, emulator, filename, content, "saves", "saveFile")
+// UploadSaveFrom streams a save from content to RomM without buffering it in memory
+func (c *Client) UploadSaveFrom(romID uint, emulator, filename string, content io.Reader) (types.ServerSave, error) {
+	asset, err := c.uploadAsset(romID, emulator, filename, content, "saves", "saveFile")
+	return types.ServerSave{ServerAsset: asset}, err
 }
 
-// UploadState uploads a save state file to RomM
-func (c *Client) UploadState(romID uint, emulator, filename string, content []byte) error {
-	return c.uploadAsset(romID, emulator, filename, content, "states", "stateFile")
+// UploadStateFrom streams a save state from content to RomM without buffering it in memory
+func (c *Client) UploadStateFrom(romID uint, emulator, filename string, content io.Reader) (types.ServerState, error) {
+	asset, err := c.uploadAsset(romID, emulator, filename, content, "states", "stateFile")
+	return types.ServerState{ServerAsset: asset}, err
 }
 
-func (c *Client) uploadAsset(romID uint, emulator, filename string, content []byte, endpoint, fieldName string) error {
-	if c.Token == "" {
-		return fmt.Errorf("not authenticated")
+func (c *Client) uploadAsset(romID uint, emulator, filename string, content io.Reader, endpoint, fieldName string) (types.ServerAsset, error) {
+	if c.Token() == "" {
+		return types.ServerAsset{}, errNotAuthenticated
 	}
 
 	params := url.Values{}
@@ -375,58 +517,81 @@ func (c *Client) uploadAsset(romID uint, emulator, filename string, content []by
 
 	urlStr := fmt.Sprintf("%s/api/%s?%s", c.BaseURL, endpoint, params.Encode())
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(fieldName, filename)
-	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
-	}
-	_, err = part.Write(content)
-	if err != nil {
-		return fmt.Errorf("failed to write content to form file: %w", err)
-	}
-	err = writer.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
-	}
+	// The multipart body is produced while the request is sent, so the content is
+	// never held in memory as a whole.
+	body, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	defer func() {
+		// Closing the body unblocks the writer if the request stopped reading it;
+		// waiting for it ensures content is no longer read once the upload returns.
+		_ = body.Close()
+		<-done
+	}()
+	go func() {
+		defer close(done)
+		part, err := writer.CreateFormFile(fieldName, filename)
+		if err != nil {
+			err = fmt.Errorf("failed to create form file: %w", err)
+		} else if _, err = io.Copy(part, content); err != nil {
+			err = fmt.Errorf("failed to write content to form file: %w", err)
+		} else if err = writer.Close(); err != nil {
+			err = fmt.Errorf("failed to close multipart writer: %w", err)
+		}
+		pw.CloseWithError(err) //nolint:errcheck
+	}()
 
 	req, err := http.NewRequest("POST", urlStr, body)
 	if err != nil {
-		return fmt.Errorf("failed to create upload request: %w", err)
+		return types.ServerAsset{}, fmt.Errorf("failed to create upload request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("accept", "application/json")
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	// Uploads can be as large as downloads, so they share the long file timeout.
+	resp, err := c.do(c.FileClient, req, "failed to perform upload request") //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return fmt.Errorf("failed to perform upload request: %w", err)
+		return types.ServerAsset{}, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		respBody, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(respBody))
+		return types.ServerAsset{}, c.statusError(resp, "upload failed")
 	}
 
-	return nil
+	// The created record is informational; servers that answer without a JSON body
+	// still count as a successful upload.
+	var asset types.ServerAsset
+	if respBody, err := c.readAllWithLimit(resp.Body, MaxMetadataSize); err == nil && len(respBody) > 0 {
+		_ = json.Unmarshal(respBody, &asset)
+	}
+	return asset, nil
 }
 
 // GetSaves fetches the list of saves from the RomM server for a given ROM
 func (c *Client) GetSaves(romID uint) ([]types.ServerSave, error) {
-	return fetchAssets[types.ServerSave](c, fmt.Sprintf("%s/api/saves?rom_id=%d", c.BaseURL, romID), "saves")
+	saves, err := fetchAssets[types.ServerSave](c, fmt.Sprintf("%s/api/saves?rom_id=%d", c.BaseURL, romID), "saves")
+	for i := range saves {
+		saves[i].Device = ParseDeviceName(saves[i].FileName)
+	}
+	return saves, err
 }
 
 // GetStates fetches the list of states from the RomM server for a given ROM
 func (c *Client) GetStates(romID uint) ([]types.ServerState, error) {
-	return fetchAssets[types.ServerState](c, fmt.Sprintf("%s/api/states?rom_id=%d", c.BaseURL, romID), "states")
+	states, err := fetchAssets[types.ServerState](c, fmt.Sprintf("%s/api/states?rom_id=%d", c.BaseURL, romID), "states")
+	for i := range states {
+		states[i].Device = ParseDeviceName(states[i].FileName)
+	}
+	return states, err
 }
 
 // fetchAssets is a generic helper that fetches a JSON list from a RomM API endpoint.
 func fetchAssets[T any](c *Client, urlStr, assetType string) ([]T, error) {
-	if c.Token == "" {
-		return nil, fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, errNotAuthenticated
 	}
 
 	req, err := http.NewRequest("GET", urlStr, http.NoBody)
@@ -434,17 +599,16 @@ func fetchAssets[T any](c *Client, urlStr, assetType string) ([]T, error) {
 		return nil, fmt.Errorf("failed to create %s request: %w", assetType, err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	resp, err := c.do(c.APIClient, req, fmt.Sprintf("failed to perform %s request", assetType)) //nolint:bodyclose // body is closed via fileio.Close wrapper
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform %s request: %w", assetType, err)
+		return nil, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return nil, fmt.Errorf("%s fetch failed with status %d: %s", assetType, resp.StatusCode, string(body))
+		return nil, c.statusError(resp, fmt.Sprintf("%s fetch failed", assetType))
 	}
 
 	bodyBytes, err := c.readAllWithLimit(resp.Body, MaxMetadataSize)
@@ -464,6 +628,50 @@ func fetchAssets[T any](c *Client, urlStr, assetType string) ([]T, error) {
 	return items, nil
 }
 
+// DeleteSaves deletes saves from the RomM server by ID
+func (c *Client) DeleteSaves(ids []uint) error {
+	return c.deleteAssets(ids, "saves")
+}
+
+// DeleteStates deletes states from the RomM server by ID
+func (c *Client) DeleteStates(ids []uint) error {
+	return c.deleteAssets(ids, "states")
+}
+
+// deleteAssets calls RomM's bulk delete endpoint, which takes the IDs as a JSON
+// list named after the asset type, e.g. {"saves": [1, 2]}.
+func (c *Client) deleteAssets(ids []uint, assetType string) error {
+	if c.Token() == "" {
+		return errNotAuthenticated
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string][]uint{assetType: ids})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s delete request: %w", assetType, err)
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/%s/delete", c.BaseURL, assetType), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s delete request: %w", assetType, err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.Token())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(c.APIClient, req, fmt.Sprintf("failed to perform %s delete request", assetType)) //nolint:bodyclose // body is closed via fileio.Close wrapper
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return c.statusError(resp, fmt.Sprintf("%s delete failed", assetType))
+	}
+	return nil
+}
+
 // DownloadSave fetches a save file from RomM using its ID
 func (c *Client) DownloadSave(ctx context.Context, id uint) (reader io.ReadCloser, filename string, err error) {
 	return c.downloadAsset(ctx, id, "saves", "unknown.sav")
@@ -475,8 +683,8 @@ func (c *Client) DownloadState(ctx context.Context, id uint) (reader io.ReadClos
 }
 
 func (c *Client) downloadAsset(ctx context.Context, id uint, assetType, fallbackFilename string) (reader io.ReadCloser, filename string, err error) {
-	if c.Token == "" {
-		return nil, "", fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, "", errNotAuthenticated
 	}
 
 	urlPath := fmt.Sprintf("%s/api/%s/%d/content", c.BaseURL, assetType, id)
@@ -485,17 +693,17 @@ func (c *Client) downloadAsset(ctx context.Context, id uint, assetType, fallback
 		return nil, "", fmt.Errorf("failed to create download request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.FileClient.Do(req) //nolint:bodyclose // caller closes
+	resp, err := c.do(c.FileClient, req, "failed to perform download request") //nolint:bodyclose // caller closes
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to perform download request: %w", err)
+		return nil, "", err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
+		err := c.statusError(resp, "download failed")
 		_ = resp.Body.Close()
-		return nil, "", fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, "", err
 	}
 
 	filename = fallbackFilename
@@ -538,17 +746,16 @@ func (c *Client) getJSON(urlStr, label string) (json.RawMessage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create %s request: %w", label, err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.APIClient.Do(req) //nolint:bodyclose // body is closed via fileio.Close
+	resp, err := c.do(c.APIClient, req, fmt.Sprintf("failed to perform %s request", label)) //nolint:bodyclose // body is closed via fileio.Close
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform %s request: %w", label, err)
+		return nil, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
-		return nil, fmt.Errorf("%s fetch failed with status %d: %s", label, resp.StatusCode, string(body))
+		return nil, c.statusError(resp, fmt.Sprintf("%s fetch failed", label))
 	}
 
 	var raw json.RawMessage
@@ -607,8 +814,8 @@ func (c *Client) readAllWithLimit(r io.Reader, limit int64) ([]byte, error) {
 
 // DownloadFirmwareContent fetches a firmware file from RomM
 func (c *Client) DownloadFirmwareContent(ctx context.Context, id uint, fileName string) (reader io.ReadCloser, filename string, err error) {
-	if c.Token == "" {
-		return nil, "", fmt.Errorf("not authenticated")
+	if c.Token() == "" {
+		return nil, "", errNotAuthenticated
 	}
 
 	urlPath := fmt.Sprintf("%s/api/firmware/%d/content/%s", c.BaseURL, id, url.PathEscape(fileName))
@@ -617,17 +824,17 @@ func (c *Client) DownloadFirmwareContent(ctx context.Context, id uint, fileName
 		return nil, "", fmt.Errorf("failed to create firmware download request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Authorization", "Bearer "+c.Token())
 
-	resp, err := c.FileClient.Do(req) //nolint:bodyclose // caller closes
+	resp, err := c.do(c.FileClient, req, "failed to perform firmware download request") //nolint:bodyclose // caller closes
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to perform firmware download request: %w", err)
+		return nil, "", err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize)
+		err := c.statusError(resp, "firmware download failed")
 		_ = resp.Body.Close()
-		return nil, "", fmt.Errorf("firmware download failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, "", err
 	}
 
 	filename = fileName
@@ -3,6 +3,7 @@ package romm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"go-romm-sync/constants"
 	"go-romm-sync/types"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogin(t *testing.T) {
@@ -77,7 +79,7 @@ func TestGetLibrary(t *testing.T) {
 		defer server.Close()
 
 		client := NewClient(server.URL)
-		client.Token = "test-token"
+		client.SetToken("test-token")
 
 		games, _, err := client.GetLibrary(30, 0, 1, "")
 		if err != nil {
@@ -103,7 +105,7 @@ func TestGetLibrary(t *testing.T) {
 		defer server.Close()
 
 		client := NewClient(server.URL)
-		client.Token = "test-token"
+		client.SetToken("test-token")
 
 		_, _, err := client.GetLibrary(30, 0, 0, "zelda")
 		if err != nil {
@@ -137,7 +139,7 @@ func TestDownloadCover(t *testing.T) {
 		port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
 
 		client := NewClient("http://romm.local:" + port)
-		client.Token = "test-token"
+		client.SetToken("test-token")
 		client.FileClient = &http.Client{Transport: &http.Transport{DialContext: customDialer}}
 
 		data, err := client.DownloadCover("/cover.jpg")
@@ -161,7 +163,7 @@ func TestDownloadCover(t *testing.T) {
 		port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
 
 		client := NewClient("http://romm.local")
-		client.Token = "test-token"
+		client.SetToken("test-token")
 		client.FileClient = &http.Client{Transport: &http.Transport{DialContext: customDialer}}
 
 		data, err := client.DownloadCover("http://cdn.local:" + port + "/cover.png")
@@ -172,7 +174,7 @@ func TestDownloadCover(t *testing.T) {
 
 	t.Run("malicious subdomain - no auth", func(t *testing.T) {
 		client := NewClient("http://romm.example.com")
-		client.Token = "test-token"
+		client.SetToken("test-token")
 
 		maliciousURL := "http://romm.example.com.attacker.com/exploit.jpg"
 		if client.shouldSendToken(maliciousURL) {
@@ -193,7 +195,7 @@ func TestGetPlatforms(t *testing.T) {
 		defer server.Close()
 
 		client := NewClient(server.URL)
-		client.Token = "test-token"
+		client.SetToken("test-token")
 		platforms, total, err := client.GetPlatforms(50, 10)
 		if err != nil {
 			t.Fatalf("GetPlatforms failed: %v", err)
@@ -211,7 +213,7 @@ func TestGetPlatforms(t *testing.T) {
 		defer server.Close()
 
 		client := NewClient(server.URL)
-		client.Token = "test-token"
+		client.SetToken("test-token")
 		platforms, total, err := client.GetPlatforms(30, 0)
 		if err != nil {
 			t.Fatalf("GetPlatforms failed: %v", err)
@@ -233,7 +235,7 @@ func TestGetRom(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	client.Token = "test-token"
+	client.SetToken("test-token")
 
 	game, err := client.GetRom(1)
 	if err != nil {
@@ -252,7 +254,7 @@ func TestDownloadFile(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	client.Token = "test-token"
+	client.SetToken("test-token")
 
 	game := &types.Game{ID: 1, FullPath: "SNES/Game.sfc"}
 	reader, filename, err := client.DownloadFile(context.Background(), game)
@@ -271,27 +273,153 @@ func TestDownloadFile(t *testing.T) {
 	}
 }
 
+func TestDownloadFileRange(t *testing.T) {
+	content := "0123456789"
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "game.sfc", modified, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetToken("test-token")
+	game := &types.Game{ID: 1, FullPath: "SNES/Game.sfc"}
+
+	tests := []struct {
+		name       string
+		ifRange    string
+		wantOffset int64
+		wantBody   string
+	}{
+		{"matching validator", `"v1"`, 4, "456789"},
+		{"changed file", `"v0"`, 0, content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := client.DownloadFileRange(context.Background(), game, 4, tt.ifRange)
+			if err != nil {
+				t.Fatalf("DownloadFileRange failed: %v", err)
+			}
+			defer d.Body.Close()
+			data, _ := io.ReadAll(d.Body)
+			if d.Offset != tt.wantOffset || string(data) != tt.wantBody {
+				t.Errorf("Expected %q at offset %d, got %q at offset %d", tt.wantBody, tt.wantOffset, data, d.Offset)
+			}
+			if d.Size != int64(len(content)) || d.ETag != `"v1"` {
+				t.Errorf("Expected size %d and ETag \"v1\", got %d and %s", len(content), d.Size, d.ETag)
+			}
+		})
+	}
+}
+
+func TestDownloadRomFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/roms/1/content/Game (Disc 2).cue" || r.URL.Query().Get("file_ids") != "12" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte("disc 2"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetToken("test-token")
+
+	game := &types.Game{ID: 1, FullPath: "PSX/Game"}
+	file := &types.RomFile{ID: 12, FileName: "Game (Disc 2).cue"}
+	d, err := client.DownloadRomFile(context.Background(), game, file, 0, "")
+	if err != nil {
+		t.Fatalf("DownloadRomFile failed: %v", err)
+	}
+	defer d.Body.Close()
+
+	data, _ := io.ReadAll(d.Body)
+	if d.Filename != file.FileName || string(data) != "disc 2" {
+		t.Errorf("Expected disc 2 as %s, got %q as %s", file.FileName, data, d.Filename)
+	}
+}
+
 func TestUploadAsset(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
 			t.Errorf("Expected POST, got %s", r.Method)
 		}
 		w.WriteHeader(http.StatusCreated)
+		if strings.Contains(r.URL.Path, "saves") {
+			w.Write([]byte(`{"id": 7, "file_name": "save [2024-03-01_10-00-00].srm", "emulator": "snes9x", "updated_at": "2024-03-01T10:00:00Z"}`))
+		}
 	}))
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	client.Token = "test-token"
+	client.SetToken("test-token")
 
-	err := client.UploadSave(1, "snes9x", "save.srm", []byte("save data"))
+	save, err := client.UploadSave(1, "snes9x", "save.srm", []byte("save data"))
 	if err != nil {
 		t.Fatalf("UploadSave failed: %v", err)
 	}
+	if save.ID != 7 || save.UpdatedAt != "2024-03-01T10:00:00Z" {
+		t.Errorf("Expected created save record to be decoded, got %+v", save)
+	}
 
-	err = client.UploadState(1, "snes9x", "state.st0", []byte("state data"))
+	state, err := client.UploadState(1, "snes9x", "state.st0", []byte("state data"))
 	if err != nil {
 		t.Fatalf("UploadState failed: %v", err)
 	}
+	if state.ID != 0 {
+		t.Errorf("Expected empty state record for empty response, got %+v", state)
+	}
+}
+
+// failingReader returns an error after producing some content.
+type failingReader struct{ sent bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("disk read failed")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestUploadAsset_Streams(t *testing.T) {
+	content := strings.Repeat("state data ", 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "saves") {
+			// The failing upload is cut off by the client.
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.ContentLength != -1 {
+			t.Errorf("Expected a streamed body of unknown length, got %d", r.ContentLength)
+		}
+		file, header, err := r.FormFile("stateFile")
+		if err != nil {
+			t.Errorf("Expected stateFile part: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "big.state" || string(data) != content {
+			t.Errorf("Unexpected upload %s of %d bytes", header.Filename, len(data))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetToken("test-token")
+
+	if _, err := client.UploadStateFrom(1, "snes9x", "big.state", strings.NewReader(content)); err != nil {
+		t.Fatalf("UploadStateFrom failed: %v", err)
+	}
+
+	_, err := client.UploadSaveFrom(1, "snes9x", "save.srm", &failingReader{})
+	if err == nil || !strings.Contains(err.Error(), "disk read failed") {
+		t.Errorf("Expected the read error to fail the upload, got %v", err)
+	}
 }
 
 func TestGetSavesStates(t *testing.T) {
@@ -306,7 +434,7 @@ func TestGetSavesStates(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	client.Token = "test-token"
+	client.SetToken("test-token")
 
 	saves, err := client.GetSaves(1)
 	if err != nil {
@@ -325,6 +453,49 @@ func TestGetSavesStates(t *testing.T) {
 	}
 }
 
+func TestDeleteAssets(t *testing.T) {
+	var paths []string
+	var bodies []map[string][]uint
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		var body map[string][]uint
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode delete body: %v", err)
+		}
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		if len(body["states"]) > 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		w.Write([]byte("[1, 2]"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetToken("test-token")
+
+	if err := client.DeleteSaves([]uint{1, 2}); err != nil {
+		t.Fatalf("DeleteSaves failed: %v", err)
+	}
+	if err := client.DeleteStates([]uint{3}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected DeleteStates to report status 404, got %v", err)
+	}
+	if err := client.DeleteSaves(nil); err != nil {
+		t.Errorf("Expected deleting nothing to succeed, got %v", err)
+	}
+
+	if len(paths) != 2 || paths[0] != "/api/saves/delete" || paths[1] != "/api/states/delete" {
+		t.Errorf("Unexpected delete requests: %v", paths)
+	}
+	if len(bodies) == 2 && (len(bodies[0]["saves"]) != 2 || bodies[1]["states"][0] != 3) {
+		t.Errorf("Unexpected delete bodies: %v", bodies)
+	}
+}
+
 func TestDownloadAsset(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Disposition", `attachment; filename="test.sav"`)
@@ -333,7 +504,7 @@ func TestDownloadAsset(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL)
-	client.Token = "test-token"
+	client.SetToken("test-token")
 
 	// Test DownloadSave
 	reader, filename, err := client.DownloadSave(context.Background(), 1)
@@ -0,0 +1,57 @@
+package romm
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDeviceNameLength bounds the device name embedded in uploaded file names.
+const maxDeviceNameLength = 32
+
+// deviceTagRe matches the " {@Steam Deck}" tag naming the device that uploaded a
+// save or state. RomM keeps it in the file name, ahead of its own timestamp.
+var deviceTagRe = regexp.MustCompile(` \{@([^{}]+)\}`)
+
+// TagDeviceName inserts a device tag before the extension of a file name, as in
+// "game.srm" -> "game {@Steam Deck}.srm". An empty device leaves the name as is.
+func TagDeviceName(filename, device string) string {
+	device = sanitizeDeviceName(device)
+	if device == "" {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + " {@" + device + "}" + ext
+}
+
+// ParseDeviceName returns the device named in a server file name, or "".
+func ParseDeviceName(filename string) string {
+	m := deviceTagRe.FindStringSubmatch(filename)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// StripDeviceTag removes the device tag from a server file name.
+func StripDeviceTag(filename string) string {
+	return deviceTagRe.ReplaceAllString(filename, "")
+}
+
+// sanitizeDeviceName drops characters that are not allowed in file names or that
+// would end the tag early, and shortens the name to maxDeviceNameLength.
+func sanitizeDeviceName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|{}`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+	for utf8.RuneCountInString(name) > maxDeviceNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return strings.TrimSpace(name)
+}
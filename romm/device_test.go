@@ -0,0 +1,55 @@
+package romm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTagDeviceName(t *testing.T) {
+	tests := []struct {
+		filename, device, want string
+	}{
+		{"game.srm", "Steam Deck", "game {@Steam Deck}.srm"},
+		{"ULUS10041DATA", "deck", "ULUS10041DATA {@deck}"},
+		{"game.srm", "", "game.srm"},
+		{"game.srm", " living/room: {PC} ", "game {@livingroom PC}.srm"},
+		{"game.srm", "a-very-long-device-name-that-goes-on-and-on", "game {@a-very-long-device-name-that-goe}.srm"},
+	}
+	for _, tt := range tests {
+		got := TagDeviceName(tt.filename, tt.device)
+		if got != tt.want {
+			t.Errorf("TagDeviceName(%q, %q) = %q, want %q", tt.filename, tt.device, got, tt.want)
+		}
+		if StripDeviceTag(got) != tt.filename {
+			t.Errorf("StripDeviceTag(%q) = %q, want %q", got, StripDeviceTag(got), tt.filename)
+		}
+	}
+}
+
+func TestParseDeviceName(t *testing.T) {
+	if got := ParseDeviceName("game {@Steam Deck} [2024-03-01_10-20-30].srm"); got != "Steam Deck" {
+		t.Errorf("Expected Steam Deck, got %q", got)
+	}
+	if got := ParseDeviceName("game [2024-03-01_10-20-30].srm"); got != "" {
+		t.Errorf("Expected no device, got %q", got)
+	}
+}
+
+func TestGetSaves_ParsesDevice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "file_name": "game {@deck} [2024-03-01_10-20-30].srm"}, {"id": 2, "file_name": "game.srm"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.SetToken("test-token")
+
+	saves, err := client.GetSaves(1)
+	if err != nil {
+		t.Fatalf("GetSaves failed: %v", err)
+	}
+	if len(saves) != 2 || saves[0].Device != "deck" || saves[1].Device != "" {
+		t.Errorf("Unexpected devices: %+v", saves)
+	}
+}
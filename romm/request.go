@@ -0,0 +1,242 @@
+package romm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how often NewClient retries a failed idempotent request.
+	DefaultMaxRetries = 2
+	// DefaultRetryDelay is the backoff before the first retry; it doubles with every
+	// further attempt.
+	DefaultRetryDelay = 500 * time.Millisecond
+
+	maxRetryDelay = 10 * time.Second
+)
+
+// ErrorKind classifies a failed RomM request. Each kind is an error itself, so
+// callers test for it with errors.Is(err, romm.ErrUnreachable).
+type ErrorKind string
+
+func (k ErrorKind) Error() string { return string(k) }
+
+const (
+	// ErrUnreachable means no connection to the server could be made.
+	ErrUnreachable ErrorKind = "server unreachable"
+	// ErrTimeout means the server accepted the connection but did not answer in time.
+	ErrTimeout ErrorKind = "request timed out"
+	// ErrNetwork means the connection failed after it was made, e.g. it was reset.
+	ErrNetwork ErrorKind = "network error"
+	// ErrUnauthorized means the server rejected the credentials (401 or 403), or
+	// the client is not logged in.
+	ErrUnauthorized ErrorKind = "not authorized"
+	// ErrNotFound means the requested resource does not exist (404).
+	ErrNotFound ErrorKind = "not found"
+	// ErrServer means the server failed to handle the request (5xx).
+	ErrServer ErrorKind = "server error"
+	// ErrRejected means the server rejected the request for another reason (4xx).
+	ErrRejected ErrorKind = "request rejected"
+)
+
+// Error is a failed RomM request.
+type Error struct {
+	Kind       ErrorKind
+	StatusCode int    // 0 when no response was received
+	Message    string // what failed, e.g. "saves fetch failed with status 404: ..."
+	Err        error  // transport error, when no response was received
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches the kind of the error, so errors.Is(err, ErrNotFound) holds for a 404.
+func (e *Error) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+// errNotAuthenticated is returned by calls made before logging in.
+var errNotAuthenticated = &Error{Kind: ErrUnauthorized, Message: "not authenticated"}
+
+func statusKind(status int) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status >= 500:
+		return ErrServer
+	}
+	return ErrRejected
+}
+
+// transportKind classifies an error returned by http.Client.Do. Failing to dial,
+// including a dial timeout, means the server is unreachable; a timeout after that
+// means it is slow.
+func transportKind(err error) ErrorKind {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrUnreachable
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ErrUnreachable
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return ErrTimeout
+	}
+	return ErrNetwork
+}
+
+// statusError builds the error for an unexpected response status, keeping the
+// start of the response body for context. The caller closes the body.
+func (c *Client) statusError(resp *http.Response, what string) error {
+	msg := fmt.Sprintf("%s with status %d", what, resp.StatusCode)
+	if body, _ := c.readAllWithLimit(resp.Body, MaxMetadataSize); len(body) > 0 {
+		msg += ": " + string(body)
+	}
+	return &Error{Kind: statusKind(resp.StatusCode), StatusCode: resp.StatusCode, Message: msg}
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends a request and returns the response whatever its status. GET requests
+// that fail with a transport error or a gateway status are retried up to
+// MaxRetries times with jittered exponential backoff; other requests are sent
+// once, as they may not be safe to repeat. A request rejected with 401 is replayed
+// once after Reauth logged in again. Transport failures are returned as *Error,
+// wrapped in what describes the failed call.
+func (c *Client) do(client *http.Client, req *http.Request, what string) (*http.Response, error) {
+	resp, err := c.send(client, req, what)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	sent, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || !c.reauthenticate(sent) {
+		return resp, nil
+	}
+	replay, ok := c.replayRequest(req)
+	if !ok {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	return c.send(client, replay, what)
+}
+
+func (c *Client) send(client *http.Client, req *http.Request, what string) (*http.Response, error) {
+	retries := 0
+	if req.Method == http.MethodGet {
+		retries = c.MaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req) //nolint:bodyclose // returned to the caller or closed before a retry
+		if err == nil && (attempt >= retries || !retryableStatus(resp.StatusCode)) {
+			return resp, nil
+		}
+		if err != nil && (attempt >= retries || req.Context().Err() != nil) {
+			return nil, &Error{Kind: transportKind(err), Message: what, Err: err}
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if !c.sleep(req.Context(), attempt) {
+			return nil, &Error{Kind: ErrNetwork, Message: what, Err: req.Context().Err()}
+		}
+	}
+}
+
+// reauthenticate replaces the rejected token and reports whether a new one is in
+// place. Only one re-login runs at a time: requests rejected with the same token
+// wait for it and then pick up the new token. Requests rejected with another token
+// while it runs fail instead of waiting, because they are the re-login's own
+// requests, such as checking a stored client token.
+func (c *Client) reauthenticate(rejected string) bool {
+	if c.Reauth == nil {
+		return false
+	}
+	c.mu.Lock()
+	if done := c.reauthDone; done != nil {
+		waiting := rejected == c.reauthRejected
+		c.mu.Unlock()
+		if !waiting {
+			return false
+		}
+		<-done
+		token := c.Token()
+		return token != "" && token != rejected
+	}
+	if c.token != "" && c.token != rejected {
+		c.mu.Unlock()
+		return true
+	}
+	done := make(chan struct{})
+	c.reauthDone, c.reauthRejected = done, rejected
+	c.mu.Unlock()
+
+	token, err := c.Reauth()
+	ok := err == nil && token != "" && token != rejected
+
+	c.mu.Lock()
+	if ok {
+		c.token = token
+	}
+	c.reauthDone, c.reauthRejected = nil, ""
+	c.mu.Unlock()
+	close(done)
+	return ok
+}
+
+// replayRequest copies a request with the current token. Requests whose body
+// cannot be produced again, such as streamed uploads, are not replayed.
+func (c *Client) replayRequest(req *http.Request) (*http.Request, bool) {
+	replay := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		replay.Body = body
+	}
+	replay.Header.Set("Authorization", "Bearer "+c.Token())
+	return replay, true
+}
+
+// sleep waits before the given retry and reports whether the context is still
+// live afterwards. The wait is drawn from the upper half of the backoff window,
+// so clients that failed together do not retry together.
+func (c *Client) sleep(ctx context.Context, attempt int) bool {
+	if c.RetryDelay <= 0 {
+		return ctx.Err() == nil
+	}
+	delay := min(c.RetryDelay<<attempt, maxRetryDelay)
+	t := time.NewTimer(delay/2 + rand.N(delay/2+1))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,262 @@
+package romm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(url string) *Client {
+	c := NewClient(url)
+	c.SetToken("token")
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	saves, err := newTestClient(server.URL).GetSaves(1)
+	if err != nil {
+		t.Fatalf("GetSaves failed: %v", err)
+	}
+	if len(saves) != 1 || calls.Load() != 3 {
+		t.Errorf("Expected success on the third attempt, got %d saves after %d attempts", len(saves), calls.Load())
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+
+	_, err := c.GetSaves(1)
+	if !errors.Is(err, ErrServer) || calls.Load() != int32(c.MaxRetries+1) {
+		t.Errorf("Expected a server error after %d attempts, got %v after %d", c.MaxRetries+1, err, calls.Load())
+	}
+	var rommErr *Error
+	if !errors.As(err, &rommErr) || rommErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the status code in the error, got %#v", err)
+	}
+
+	// Deleting is a POST and is not repeated.
+	calls.Store(0)
+	if err := c.DeleteSaves([]uint{1}); !errors.Is(err, ErrServer) || calls.Load() != 1 {
+		t.Errorf("Expected a single attempt, got %v after %d", err, calls.Load())
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		kind   ErrorKind
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusBadRequest, ErrRejected},
+	} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(tc.status)
+		}))
+		_, err := newTestClient(server.URL).GetRom(1)
+		server.Close()
+		if !errors.Is(err, tc.kind) {
+			t.Errorf("status %d: expected %q, got %v", tc.status, tc.kind, err)
+		}
+		if errors.Is(err, ErrUnreachable) {
+			t.Errorf("status %d: a response must not count as unreachable", tc.status)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("status %d: expected no retries, got %d attempts", tc.status, calls.Load())
+		}
+	}
+
+	if _, err := NewClient("http://romm").GetRom(1); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected an unauthenticated client to fail with ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	// A closed port refuses the connection.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	if _, err := newTestClient("http://" + addr).GetRom(1); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("Expected ErrUnreachable, got %v", err)
+	}
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	c := newTestClient(server.URL)
+	c.APIClient.Timeout = 20 * time.Millisecond
+	c.MaxRetries = 0
+	_, err = c.GetRom(1)
+	if !errors.Is(err, ErrTimeout) || errors.Is(err, ErrUnreachable) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL)
+	c.RetryDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.DownloadSave(ctx, 1)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the backoff to stop when the context is cancelled")
+	}
+}
+
+func TestReauthOn401(t *testing.T) {
+	var rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"saves":[1]}` {
+				t.Errorf("Expected the replayed body, got %q", body)
+			}
+		}
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.SetToken("revoked")
+	logins := 0
+	c.Reauth = func() (string, error) {
+		logins++
+		return "fresh", nil
+	}
+	if _, err := c.GetRom(1); err != nil {
+		t.Fatalf("Expected the request to be replayed after logging in, got %v", err)
+	}
+	if c.Token() != "fresh" || logins != 1 || rejected.Load() != 1 {
+		t.Errorf("Expected one login and one rejection, got token %q, %d logins, %d rejections", c.Token(), logins, rejected.Load())
+	}
+
+	// Request bodies are sent again.
+	c.SetToken("revoked")
+	if err := c.DeleteSaves([]uint{1}); err != nil {
+		t.Errorf("Expected the delete to be replayed, got %v", err)
+	}
+
+	// A failed login returns the original rejection.
+	c.SetToken("revoked")
+	c.Reauth = func() (string, error) {
+		logins++
+		return "", errors.New("bad password")
+	}
+	logins = 0
+	if _, err := c.GetRom(1); !errors.Is(err, ErrUnauthorized) || logins != 1 {
+		t.Errorf("Expected ErrUnauthorized after one login attempt, got %v after %d", err, logins)
+	}
+
+	// The login itself is not re-authenticated.
+	c.Reauth = func() (string, error) {
+		logins++
+		return "fresh", nil
+	}
+	logins = 0
+	if _, err := c.Login("user", "pass"); !errors.Is(err, ErrUnauthorized) || logins != 0 {
+		t.Errorf("Expected the login to fail without re-authentication, got %v after %d logins", err, logins)
+	}
+}
+
+func TestReauthConcurrent401s(t *testing.T) {
+	var rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	c.SetToken("revoked")
+	var logins atomic.Int32
+	c.Reauth = func() (string, error) {
+		logins.Add(1)
+		// Both requests were rejected with the old token before the login finishes.
+		for deadline := time.Now().Add(5 * time.Second); rejected.Load() < 2 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		// Requests of the login itself fail instead of waiting for it.
+		c.SetToken("stored-client-token")
+		if _, err := c.GetRom(1); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Expected the login's own request to be rejected, got %v", err)
+		}
+		return "fresh", nil
+	}
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := c.GetRom(1)
+			errs <- err
+		}()
+	}
+	for range 2 {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("Expected both requests to be replayed with the new token, got %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("Expected the requests to finish after the login")
+		}
+	}
+	if logins.Load() != 1 || c.Token() != "fresh" {
+		t.Errorf("Expected one login leaving the fresh token, got %d logins and token %q", logins.Load(), c.Token())
+	}
+}
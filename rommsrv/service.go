@@ -19,17 +19,37 @@ type ConfigProvider interface {
 type Service struct {
 	config ConfigProvider
 	client *romm.Client
+	reauth func() error
 }
 
 // New creates a new RomM service.
 func New(cfg ConfigProvider) *Service {
-	host := cfg.GetRomMHost()
+	s := &Service{config: cfg}
+	s.client = s.newClient(cfg.GetRomMHost())
+	s.client.SetToken(cfg.GetClientToken())
+	return s
+}
+
+func (s *Service) newClient(host string) *romm.Client {
 	client := romm.NewClient(host)
-	client.Token = cfg.GetClientToken()
-	return &Service{
-		config: cfg,
-		client: client,
+	if s.reauth != nil {
+		client.Reauth = s.reauthenticate
+	}
+	return client
+}
+
+// SetReauthenticator installs the login that runs when RomM rejects the current
+// token. It must leave the new token on the active client.
+func (s *Service) SetReauthenticator(fn func() error) {
+	s.reauth = fn
+	s.client.Reauth = s.reauthenticate
+}
+
+func (s *Service) reauthenticate() (string, error) {
+	if err := s.reauth(); err != nil {
+		return "", err
 	}
+	return s.client.Token(), nil
 }
 
 // Login authenticates with the RomM server and returns a token.
@@ -44,7 +64,7 @@ func (s *Service) Login() (string, error) {
 
 	// Ensure client is up to date with current config
 	if s.client.BaseURL == "" || s.client.BaseURL != host {
-		s.client = romm.NewClient(host)
+		s.client = s.newClient(host)
 	}
 
 	token, err := s.client.Login(user, pass)
@@ -60,12 +80,12 @@ func (s *Service) GetClient() *romm.Client {
 
 // SetClientToken updates the active client's auth token.
 func (s *Service) SetClientToken(token string) {
-	s.client.Token = token
+	s.client.SetToken(token)
 }
 
 // ResetClient re-initialises the RomM client, clearing any in-memory session.
 func (s *Service) ResetClient() {
-	s.client = romm.NewClient(s.config.GetRomMHost())
+	s.client = s.newClient(s.config.GetRomMHost())
 }
 
 // CreateClientToken creates a persistent client token via the RomM API.
@@ -147,6 +167,16 @@ func (s *Service) GetServerStates(id uint) ([]types.ServerState, error) {
 	return s.client.GetStates(id)
 }
 
+// DeleteServerSaves deletes saves from RomM.
+func (s *Service) DeleteServerSaves(ids []uint) error {
+	return s.client.DeleteSaves(ids)
+}
+
+// DeleteServerStates deletes states from RomM.
+func (s *Service) DeleteServerStates(ids []uint) error {
+	return s.client.DeleteStates(ids)
+}
+
 // DownloadCover downloads a cover image from RomM using the active client.
 func (s *Service) DownloadCover(url string) ([]byte, error) {
 	return s.client.DownloadCover(url)
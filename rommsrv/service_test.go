@@ -78,7 +78,7 @@ func TestGetLibrary(t *testing.T) {
 
 	cfg := &MockConfigProvider{Host: server.URL}
 	s := New(cfg)
-	s.client.Token = "test-token"
+	s.client.SetToken("test-token")
 
 	games, _, err := s.GetLibrary(30, 0, 1, "")
 	if err != nil {
@@ -127,7 +127,7 @@ func TestGetPlatforms(t *testing.T) {
 
 	cfg := &MockConfigProvider{Host: server.URL}
 	s := New(cfg)
-	s.client.Token = "test-token"
+	s.client.SetToken("test-token")
 
 	t.Run("first page", func(t *testing.T) {
 		platforms, total, err := s.GetPlatforms(2, 0)
@@ -174,7 +174,7 @@ func TestGetRom(t *testing.T) {
 
 	cfg := &MockConfigProvider{Host: server.URL}
 	s := New(cfg)
-	s.client.Token = "test-token"
+	s.client.SetToken("test-token")
 
 	game, err := s.GetRom(1)
 	if err != nil {
@@ -198,7 +198,7 @@ func TestGetServerSavesStates(t *testing.T) {
 
 	cfg := &MockConfigProvider{Host: server.URL}
 	s := New(cfg)
-	s.client.Token = "test-token"
+	s.client.SetToken("test-token")
 
 	saves, err := s.GetServerSaves(1)
 	if err != nil {
@@ -217,6 +217,29 @@ func TestGetServerSavesStates(t *testing.T) {
 	}
 }
 
+func TestDeleteServerSavesStates(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`[1]`))
+	}))
+	defer server.Close()
+
+	cfg := &MockConfigProvider{Host: server.URL}
+	s := New(cfg)
+	s.client.SetToken("test-token")
+
+	if err := s.DeleteServerSaves([]uint{1}); err != nil {
+		t.Fatalf("DeleteServerSaves failed: %v", err)
+	}
+	if err := s.DeleteServerStates([]uint{1}); err != nil {
+		t.Fatalf("DeleteServerStates failed: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/api/saves/delete" || paths[1] != "/api/states/delete" {
+		t.Errorf("Unexpected delete requests: %v", paths)
+	}
+}
+
 func TestGetClient(t *testing.T) {
 	cfg := &MockConfigProvider{Host: "http://localhost"}
 	s := New(cfg)
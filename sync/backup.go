@@ -0,0 +1,259 @@
+package sync
+
+import (
+	"crypto/sha1" //nolint:gosec // only used to shorten backup directory names
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-romm-sync/types"
+	"go-romm-sync/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// backupDirName is the per-game directory, next to metadata.json, holding
+	// previous versions of saves and states.
+	backupDirName      = ".backups"
+	backupManifestName = "backup.json"
+	backupIDLayout     = "20060102T150405.000000000Z"
+
+	// defaultBackupGenerations is used when AppConfig.BackupGenerations is unset.
+	defaultBackupGenerations = 5
+)
+
+// BackupVersion describes one backed up version of a save or state.
+type BackupVersion struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"` // constants.DirSaves or constants.DirStates
+	Core      string `json:"core"`
+	Name      string `json:"name"`
+	Path      string `json:"path"` // original location, relative to the library path
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (s *Service) backupGenerations() int {
+	if n := s.config.GetConfig().BackupGenerations; n > 0 {
+		return n
+	}
+	return defaultBackupGenerations
+}
+
+func (s *Service) backupRoot(game *types.Game) string {
+	return filepath.Join(s.library.GetRomDir(game), backupDirName)
+}
+
+// backupAsset moves the current version of an asset into the game's backup area
+// before it is overwritten or deleted, then prunes old generations of that asset.
+// Nothing happens when the asset does not exist.
+func (s *Service) backupAsset(game *types.Game, subDir, core, name, path string) error {
+	st, ok := statAsset(path)
+	if !ok {
+		return nil
+	}
+	libPath := s.config.GetConfig().LibraryPath
+	rel, err := filepath.Rel(libPath, path)
+	if err != nil || !utils.IsSafePath(libPath, path) {
+		return fmt.Errorf("asset %s is outside the library", path)
+	}
+
+	now := time.Now().UTC()
+	key := journalKey(subDir, core, name)
+	sum := sha1.Sum([]byte(key)) //nolint:gosec
+	version := BackupVersion{
+		ID:        now.Format(backupIDLayout) + "-" + hex.EncodeToString(sum[:4]),
+		Type:      subDir,
+		Core:      core,
+		Name:      name,
+		Path:      filepath.ToSlash(rel),
+		Size:      st.size,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+
+	dir := filepath.Join(s.backupRoot(game), version.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := moveAsset(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	data, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, backupManifestName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	s.ui.LogInfof("backupAsset: Backed up %s to %s", path, dir)
+
+	s.pruneBackups(game, key)
+	return nil
+}
+
+func (s *Service) pruneBackups(game *types.Game, key string) {
+	versions, err := s.readBackups(game)
+	if err != nil {
+		s.ui.LogErrorf("pruneBackups: %v", err)
+		return
+	}
+	keep := s.backupGenerations()
+	for _, v := range versions {
+		if journalKey(v.Type, v.Core, v.Name) != key {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.backupRoot(game), v.ID)); err != nil {
+			s.ui.LogErrorf("pruneBackups: Failed to remove backup %s: %v", v.ID, err)
+		}
+	}
+}
+
+// readBackups returns every backup of a game, newest first.
+func (s *Service) readBackups(game *types.Game) ([]BackupVersion, error) {
+	root := s.backupRoot(game)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var versions []BackupVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, entry.Name(), backupManifestName))
+		if err != nil {
+			continue
+		}
+		var v BackupVersion
+		if err := json.Unmarshal(data, &v); err != nil || v.ID != entry.Name() {
+			s.ui.LogErrorf("readBackups: Ignoring invalid backup %s", entry.Name())
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+// ListBackups returns the backed up versions of a game's saves and states, newest first.
+func (s *Service) ListBackups(id uint) ([]BackupVersion, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	return s.readBackups(&game)
+}
+
+// RestoreBackup puts a backed up version back in its original location. The version
+// being replaced is backed up first, so a restore can itself be undone.
+func (s *Service) RestoreBackup(id uint, backupID string) error {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return fmt.Errorf("failed to get ROM info: %w", err)
+	}
+
+	root := s.backupRoot(&game)
+	dir := filepath.Join(root, backupID)
+	if !utils.IsSafePath(root, dir) || filepath.Clean(dir) == filepath.Clean(root) {
+		return fmt.Errorf("invalid path traversal detected")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, backupManifestName))
+	if err != nil {
+		return fmt.Errorf("backup %s not found: %w", backupID, err)
+	}
+	var v BackupVersion
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+
+	libPath := s.config.GetConfig().LibraryPath
+	dest := filepath.Join(libPath, filepath.FromSlash(v.Path))
+	if !utils.IsSafePath(libPath, dest) {
+		return fmt.Errorf("invalid path traversal detected")
+	}
+	src := filepath.Join(dir, filepath.Base(dest))
+
+	if err := s.backupAsset(&game, v.Type, v.Core, v.Name, dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if err := copyAsset(src, dest); err != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", backupID, err)
+	}
+	s.ui.LogInfof("RestoreBackup: Restored %s to %s", backupID, dest)
+	return nil
+}
+
+// moveAsset renames a file or directory, copying it when a rename is not possible.
+func moveAsset(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyAsset(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyAsset copies a file or a directory tree, preserving modification times.
+func copyAsset(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst, info)
+	}
+	return filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if fi.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFile(p, target, fi)
+	})
+}
+
+func copyFile(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() //nolint:errcheck
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
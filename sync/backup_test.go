@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"encoding/json"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeleteGameFile_KeepsBackups(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	cm.Config.BackupGenerations = 2
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "game.srm")
+	for _, content := range []string{"first", "second", "third"} {
+		if err := os.WriteFile(savePath, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write save: %v", err)
+		}
+		if err := s.DeleteGameFile(1, "saves", "snes9x", "game.srm"); err != nil {
+			t.Fatalf("DeleteGameFile failed: %v", err)
+		}
+		if _, err := os.Stat(savePath); !os.IsNotExist(err) {
+			t.Fatalf("Expected save to be removed, got err %v", err)
+		}
+	}
+
+	backups, err := s.ListBackups(1)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 generations to be kept, got %+v", backups)
+	}
+	if backups[0].Name != "game.srm" || backups[0].Path != "snes/1/saves/snes9x/game.srm" {
+		t.Errorf("Unexpected backup metadata: %+v", backups[0])
+	}
+
+	// Restore the older generation; the restore must not consume the backup.
+	if err := s.RestoreBackup(1, backups[1].ID); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if data, _ := os.ReadFile(savePath); string(data) != "second" {
+		t.Errorf("Expected restored content %q, got %q", "second", data)
+	}
+	if err := s.RestoreBackup(1, "../../metadata.json"); err == nil {
+		t.Error("Expected error for backup ID outside the backup directory")
+	}
+}
+
+func TestDownloadServerAsset_BacksUpExisting(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, []byte("server data"))
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("local data"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	if err := s.DownloadServerSave(1, 10, "snes9x", "game.srm", ""); err != nil {
+		t.Fatalf("DownloadServerSave failed: %v", err)
+	}
+
+	backups, err := s.ListBackups(1)
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %+v (err %v)", backups, err)
+	}
+	backed := filepath.Join(tempDir, "snes", "1", backupDirName, backups[0].ID, "game.srm")
+	if data, _ := os.ReadFile(backed); string(data) != "local data" {
+		t.Errorf("Expected backup of previous content, got %q", data)
+	}
+}
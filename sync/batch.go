@@ -0,0 +1,186 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"math"
+	stdsync "sync"
+)
+
+// libraryWorkers bounds how many games are synced concurrently.
+const libraryWorkers = 4
+
+// LibrarySyncSummary counts the saves and states handled by a library-wide sync.
+type LibrarySyncSummary struct {
+	Games      int            `json:"games"`
+	Uploaded   int            `json:"uploaded"`
+	Downloaded int            `json:"downloaded"`
+	Skipped    int            `json:"skipped"`
+	Conflicted int            `json:"conflicted"`
+	Failed     int            `json:"failed"`
+	Conflicts  []PullConflict `json:"conflicts,omitempty"`
+	Cancelled  bool           `json:"cancelled"`
+}
+
+func (s *LibrarySyncSummary) add(o *LibrarySyncSummary) {
+	s.Uploaded += o.Uploaded
+	s.Downloaded += o.Downloaded
+	s.Skipped += o.Skipped
+	s.Conflicted += o.Conflicted
+	s.Failed += o.Failed
+	s.Conflicts = append(s.Conflicts, o.Conflicts...)
+}
+
+// LibrarySyncProgress is emitted as constants.EventLibrarySyncProgress after each game.
+type LibrarySyncProgress struct {
+	GameID    uint   `json:"game_id"`
+	Title     string `json:"title"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SyncLibrary reconciles the saves and states of every downloaded game in the local
+// library in both directions. Conflicts are left untouched and reported in the summary.
+// When ctx is cancelled the games already in progress finish, the remaining games are
+// skipped and the partial summary is returned together with ctx.Err().
+func (s *Service) SyncLibrary(ctx context.Context) (LibrarySyncSummary, error) {
+	downloaded, err := s.downloadedGames()
+	if err != nil {
+		return LibrarySyncSummary{}, err
+	}
+
+	summary := LibrarySyncSummary{Games: len(downloaded)}
+	var mu stdsync.Mutex
+	completed := 0
+
+	jobs := make(chan *types.Game)
+	var wg stdsync.WaitGroup
+	for w := 0; w < libraryWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range jobs {
+				result, err := s.syncGame(ctx, game)
+
+				mu.Lock()
+				summary.add(&result)
+				completed++
+				progress := LibrarySyncProgress{GameID: game.ID, Title: game.Title, Completed: completed, Total: len(downloaded)}
+				mu.Unlock()
+
+				if err != nil {
+					s.ui.LogErrorf("SyncLibrary: Failed to sync %d: %v", game.ID, err)
+					progress.Error = err.Error()
+				}
+				s.ui.EventsEmit(constants.EventLibrarySyncProgress, progress)
+			}
+		}()
+	}
+
+feed:
+	for i := range downloaded {
+		select {
+		case jobs <- &downloaded[i]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.ui.LogInfof("SyncLibrary: %d games, %d uploaded, %d downloaded, %d skipped, %d conflicted, %d failed",
+		summary.Games, summary.Uploaded, summary.Downloaded, summary.Skipped, summary.Conflicted, summary.Failed)
+	if err := ctx.Err(); err != nil {
+		summary.Cancelled = true
+		return summary, err
+	}
+	return summary, nil
+}
+
+// downloadedGames returns the games of the local library whose ROM is on disk.
+func (s *Service) downloadedGames() ([]types.Game, error) {
+	games, _, err := s.library.GetLocalLibrary(math.MaxInt32, 0, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local library: %w", err)
+	}
+	var downloaded []types.Game
+	for i := range games {
+		if s.library.FindRomPath(s.library.GetRomDir(&games[i])) != "" {
+			downloaded = append(downloaded, games[i])
+		}
+	}
+	return downloaded, nil
+}
+
+// syncGame reconciles the saves and states of one game. The returned error reports
+// a failure to compare the game as a whole; per-file failures are only counted.
+func (s *Service) syncGame(ctx context.Context, game *types.Game) (LibrarySyncSummary, error) {
+	var result LibrarySyncSummary
+	saves, err := s.romm.GetServerSaves(game.ID)
+	if err != nil {
+		result.Failed++
+		return result, fmt.Errorf("failed to fetch server saves: %w", err)
+	}
+	states, err := s.romm.GetServerStates(game.ID)
+	if err != nil {
+		result.Failed++
+		return result, fmt.Errorf("failed to fetch server states: %w", err)
+	}
+
+	s.exportGameSaves(game)
+	for _, set := range []struct {
+		subDir string
+		server []types.ServerAsset
+	}{
+		{constants.DirSaves, serverSaveAssets(saves)},
+		{constants.DirStates, serverStateAssets(states)},
+	} {
+		statuses, err := s.assetStatuses(game, set.subDir, set.server)
+		if err != nil {
+			result.Failed++
+			return result, err
+		}
+		for i := range statuses {
+			if ctx.Err() != nil {
+				return result, nil
+			}
+			s.reconcileAsset(game, &statuses[i], &result)
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) reconcileAsset(game *types.Game, st *AssetSyncStatus, result *LibrarySyncSummary) {
+	switch planAction(st.Status) {
+	case ActionUpload:
+		if err := s.uploadServerAsset(game.ID, st.Core, st.Name, st.Type); err != nil {
+			s.ui.LogErrorf("SyncLibrary: Failed to upload %s %s/%s for %d: %v", st.Type, st.Core, st.Name, game.ID, err)
+			result.Failed++
+			return
+		}
+		result.Uploaded++
+	case ActionDownload:
+		remote := st.Server
+		if err := s.downloadServerAsset(game.ID, remote.ID, remote.Emulator, st.Name, remote.UpdatedAt, st.Type); err != nil {
+			s.ui.LogErrorf("SyncLibrary: Failed to download %s %s/%s for %d: %v", st.Type, st.Core, st.Name, game.ID, err)
+			result.Failed++
+			return
+		}
+		result.Downloaded++
+	case ActionConflict:
+		result.Conflicted++
+		result.Conflicts = append(result.Conflicts, PullConflict{
+			GameID:          game.ID,
+			Type:            st.Type,
+			Core:            st.Core,
+			Name:            st.Name,
+			LocalUpdatedAt:  st.Local.UpdatedAt,
+			ServerUpdatedAt: st.Server.UpdatedAt,
+		})
+	default:
+		result.Skipped++
+	}
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+)
+
+func writeLibraryGame(t *testing.T, libPath string, game *types.Game, withRom bool) string {
+	t.Helper()
+	romDir := filepath.Join(libPath, filepath.Dir(game.FullPath), fmt.Sprint(game.ID))
+	if err := os.MkdirAll(romDir, 0o755); err != nil {
+		t.Fatalf("failed to create rom dir: %v", err)
+	}
+	data, _ := json.Marshal(game)
+	if err := os.WriteFile(filepath.Join(romDir, "metadata.json"), data, 0o644); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+	if withRom {
+		if err := os.WriteFile(filepath.Join(romDir, filepath.Base(game.FullPath)), []byte("rom"), 0o644); err != nil {
+			t.Fatalf("failed to write rom: %v", err)
+		}
+	}
+	return romDir
+}
+
+func TestSyncLibrary(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Game 1 has a local-only save, game 2 a server-only state, game 3 is not downloaded.
+	romDir1 := writeLibraryGame(t, tempDir, &types.Game{ID: 1, Title: "One", FullPath: "snes/one.sfc"}, true)
+	writeLibraryGame(t, tempDir, &types.Game{ID: 2, Title: "Two", FullPath: "snes/two.sfc"}, true)
+	writeLibraryGame(t, tempDir, &types.Game{ID: 3, Title: "Three", FullPath: "snes/three.sfc"}, false)
+
+	savesDir := filepath.Join(romDir1, "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "one.srm"), []byte("progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	uploadedData, _ := json.Marshal([]types.ServerSave{
+		{ServerAsset: types.ServerAsset{ID: 5, FileName: "one.srm", Emulator: "snes9x", UpdatedAt: "2024-03-02T10:00:00Z"}},
+	})
+	statesData, _ := json.Marshal([]types.ServerState{
+		{ServerAsset: types.ServerAsset{ID: 20, FileName: "two.state", Emulator: "snes9x", UpdatedAt: "2024-03-01T10:00:00Z"}},
+	})
+
+	lib, romm, cm := setupServices(tempDir, nil, []byte("server state"))
+	var mu stdsync.Mutex
+	var uploads []string
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := []byte("[]")
+			switch {
+			case req.Method == http.MethodPost:
+				mu.Lock()
+				uploads = append(uploads, req.URL.Path)
+				mu.Unlock()
+				body = []byte(`{"id": 5, "updated_at": "2024-03-02T10:00:00Z"}`)
+			case req.URL.Path == "/api/saves" && req.URL.Query().Get("rom_id") == "1":
+				mu.Lock()
+				if len(uploads) > 0 {
+					body = uploadedData
+				}
+				mu.Unlock()
+			case req.URL.Path == "/api/states" && req.URL.Query().Get("rom_id") == "2":
+				body = statesData
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	summary, err := s.SyncLibrary(context.Background())
+	if err != nil {
+		t.Fatalf("SyncLibrary failed: %v", err)
+	}
+	if summary.Games != 2 || summary.Uploaded != 1 || summary.Downloaded != 1 || summary.Failed != 0 || summary.Conflicted != 0 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if len(uploads) != 1 || uploads[0] != "/api/saves" {
+		t.Errorf("Expected one save upload, got %v", uploads)
+	}
+
+	statePath := filepath.Join(tempDir, "snes", "2", "states", "snes9x", "two.state")
+	if data, err := os.ReadFile(statePath); err != nil || string(data) != "server state" {
+		t.Errorf("Expected server state to be downloaded, got %q (err %v)", data, err)
+	}
+
+	// A second run finds everything in sync.
+	summary, err = s.SyncLibrary(context.Background())
+	if err != nil {
+		t.Fatalf("SyncLibrary failed: %v", err)
+	}
+	if summary.Uploaded != 0 || summary.Downloaded != 0 {
+		t.Errorf("Expected nothing to transfer on second run, got %+v", summary)
+	}
+}
+
+func TestSyncLibrary_Cancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	writeLibraryGame(t, tempDir, &types.Game{ID: 1, FullPath: "snes/one.sfc"}, true)
+
+	lib, romm, cm := setupServices(tempDir, []byte("[]"), nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	summary, err := s.SyncLibrary(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !summary.Cancelled {
+		t.Errorf("Expected summary to be marked cancelled, got %+v", summary)
+	}
+}
@@ -0,0 +1,266 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/retroarch"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// coreSaveDirCandidates returns the folder names a core's saves may be stored in,
+// preferred first: the folder its save layout resolves it to, its name without
+// the libretro suffix and the core itself.
+func coreSaveDirCandidates(core string) []string {
+	var dirs []string
+	if dir := canonicalCore(core); dir != core {
+		dirs = append(dirs, dir)
+	}
+	if base := strings.TrimSuffix(core, "_libretro"); base != core {
+		dirs = append(dirs, base)
+	}
+	return append(dirs, core)
+}
+
+// saveConversion converts saves between two cores that store them in different
+// formats. Pairs without a conversion have their saves copied unchanged.
+type saveConversion struct {
+	name     string
+	from, to []string // cores
+	fromExt  string
+	toExt    string
+	convert  func(data []byte) ([]byte, error)
+}
+
+var melonDSCores = []string{constants.CoreMelonDS, constants.CoreMelonDSDS, constants.CoreNooDS}
+
+var saveConversions = []saveConversion{
+	{name: "dsv-to-sav", from: []string{constants.CoreDeSmuME}, to: melonDSCores, fromExt: ".dsv", toExt: ".sav", convert: dsvToRaw},
+	{name: "sav-to-dsv", from: melonDSCores, to: []string{constants.CoreDeSmuME}, fromExt: ".sav", toExt: ".dsv", convert: rawToDSV},
+}
+
+func findSaveConversion(from, to, name string) *saveConversion {
+	for i := range saveConversions {
+		c := &saveConversions[i]
+		if containsCore(c.from, from) && containsCore(c.to, to) && strings.EqualFold(filepath.Ext(name), c.fromExt) {
+			return c
+		}
+	}
+	return nil
+}
+
+func containsCore(cores []string, core string) bool {
+	for _, c := range cores {
+		if c == core {
+			return true
+		}
+	}
+	return false
+}
+
+// DeSmuME appends a footer to the raw save memory: a marker text, six little-endian
+// uint32 fields describing the memory and a closing cookie.
+const (
+	dsvFooterText   = "|<--Snip above here to create a raw sav by excluding this DeSmuME savedata footer:"
+	dsvFooterCookie = "|-DESMUME SAVE-|"
+)
+
+func dsvToRaw(data []byte) ([]byte, error) {
+	i := bytes.LastIndex(data, []byte(dsvFooterText))
+	if i < 0 || !bytes.HasSuffix(data, []byte(dsvFooterCookie)) {
+		return nil, fmt.Errorf("no DeSmuME save footer found")
+	}
+	return data[:i], nil
+}
+
+func rawToDSV(data []byte) ([]byte, error) {
+	size := uint32(len(data))
+	// Address width of the save chip: 1 byte for 512-byte EEPROM, 2 bytes up to
+	// 64 KB EEPROM and FRAM, 3 bytes for flash.
+	addrSize := uint32(3)
+	switch {
+	case size <= 512:
+		addrSize = 1
+	case size <= 64<<10:
+		addrSize = 2
+	}
+
+	var out bytes.Buffer
+	out.Write(data)
+	out.WriteString(dsvFooterText)
+	for _, v := range []uint32{size, size, 0, addrSize, size, 0} {
+		_ = binary.Write(&out, binary.LittleEndian, v)
+	}
+	out.WriteString(dsvFooterCookie)
+	return out.Bytes(), nil
+}
+
+// CoreSaveCopy describes a save of the previous core that is copied, and
+// converted when Conversion is set, into the folder of the new core.
+type CoreSaveCopy struct {
+	FromDir    string `json:"from_dir"`
+	FromName   string `json:"from_name"`
+	ToDir      string `json:"to_dir"`
+	ToName     string `json:"to_name"`
+	Conversion string `json:"conversion,omitempty"`
+}
+
+// DetectCoreSwitch returns the core whose save folder holds the game's most
+// recently written saves, when that folder is not the one of toCore. Only the
+// cores of the game's platform are considered. It returns "" when the game has no
+// saves of another core.
+func (s *Service) DetectCoreSwitch(id uint, toCore string) (string, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	if toCore == "" {
+		return "", nil
+	}
+	savesDir := filepath.Join(s.library.GetRomDir(&game), constants.DirSaves)
+	toDirs := coreSaveDirCandidates(toCore)
+
+	var fromCore string
+	var newest time.Time
+	for _, core := range retroarch.GetCoresForPlatform(getPlatformSlug(&game)) {
+		dir := existingDir(savesDir, coreSaveDirCandidates(core))
+		if dir == "" || slices.Contains(toDirs, dir) {
+			continue
+		}
+		if modTime := newestSave(filepath.Join(savesDir, dir)); modTime.After(newest) {
+			fromCore, newest = core, modTime
+		}
+	}
+	return fromCore, nil
+}
+
+// newestSave returns the modification time of the newest save directly in dir, or
+// the zero time when it holds none.
+func newestSave(dir string) time.Time {
+	var newest time.Time
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return newest
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+// PlanCoreSwitch lists the saves a game has in the folder of fromCore that are
+// missing from the folder of toCore. Saves are left in place; states are never
+// offered because they only load in the core that wrote them.
+func (s *Service) PlanCoreSwitch(id uint, fromCore, toCore string) ([]CoreSaveCopy, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	return s.planCoreSwitch(&game, fromCore, toCore), nil
+}
+
+func (s *Service) planCoreSwitch(game *types.Game, fromCore, toCore string) []CoreSaveCopy {
+	if fromCore == "" || toCore == "" || fromCore == toCore {
+		return nil
+	}
+	savesDir := filepath.Join(s.library.GetRomDir(game), constants.DirSaves)
+	fromDir := existingDir(savesDir, coreSaveDirCandidates(fromCore))
+	if fromDir == "" {
+		return nil
+	}
+	toCandidates := coreSaveDirCandidates(toCore)
+	toDir := existingDir(savesDir, toCandidates)
+	if toDir == "" {
+		toDir = toCandidates[0]
+	}
+	if toDir == fromDir {
+		return nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(savesDir, fromDir))
+	if err != nil {
+		return nil
+	}
+	var copies []CoreSaveCopy
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		c := CoreSaveCopy{FromDir: fromDir, FromName: e.Name(), ToDir: toDir, ToName: e.Name()}
+		if conv := findSaveConversion(fromCore, toCore, e.Name()); conv != nil {
+			c.ToName = strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())) + conv.toExt
+			c.Conversion = conv.name
+		}
+		if _, err := os.Stat(filepath.Join(savesDir, toDir, c.ToName)); err == nil {
+			continue
+		}
+		copies = append(copies, c)
+	}
+	return copies
+}
+
+func existingDir(parent string, names []string) string {
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(parent, name)); err == nil && info.IsDir() {
+			return name
+		}
+	}
+	return ""
+}
+
+// SwitchCoreSaves copies the saves listed by PlanCoreSwitch into the folder of the
+// new core, converting them where a conversion is registered for the two cores.
+func (s *Service) SwitchCoreSaves(id uint, fromCore, toCore string) ([]CoreSaveCopy, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+
+	savesDir := filepath.Join(s.library.GetRomDir(&game), constants.DirSaves)
+	copies := s.planCoreSwitch(&game, fromCore, toCore)
+	for _, c := range copies {
+		src := filepath.Join(savesDir, c.FromDir, c.FromName)
+		destDir := filepath.Join(savesDir, c.ToDir)
+		if err := os.MkdirAll(destDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create save directory: %w", err)
+		}
+		if err := copyCoreSave(src, filepath.Join(destDir, c.ToName), findSaveConversion(fromCore, toCore, c.FromName)); err != nil {
+			return nil, fmt.Errorf("failed to copy %s to %s: %w", c.FromName, c.ToDir, err)
+		}
+		s.ui.LogInfof("SwitchCoreSaves: Copied %s/%s to %s/%s", c.FromDir, c.FromName, c.ToDir, c.ToName)
+	}
+	return copies, nil
+}
+
+func copyCoreSave(src, dst string, conv *saveConversion) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if conv == nil {
+		return copyFile(src, dst, info)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	converted, err := conv.convert(data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, converted, 0o644); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,147 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDSVConversion(t *testing.T) {
+	raw := bytes.Repeat([]byte{0xAB}, 8<<10)
+	dsv, err := rawToDSV(raw)
+	if err != nil {
+		t.Fatalf("rawToDSV failed: %v", err)
+	}
+	if len(dsv) != len(raw)+len(dsvFooterText)+24+len(dsvFooterCookie) {
+		t.Errorf("Unexpected DSV size %d", len(dsv))
+	}
+	back, err := dsvToRaw(dsv)
+	if err != nil {
+		t.Fatalf("dsvToRaw failed: %v", err)
+	}
+	if !bytes.Equal(back, raw) {
+		t.Error("Expected the raw save back")
+	}
+	if _, err := dsvToRaw(raw); err == nil {
+		t.Error("Expected an error for a save without footer")
+	}
+}
+
+func TestSwitchCoreSaves(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "nds", FullPath: "nds/game.nds"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	savesDir := filepath.Join(tempDir, "nds", "1", "saves")
+	desmumeDir := filepath.Join(savesDir, "DeSmuME")
+	if err := os.MkdirAll(desmumeDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	raw := bytes.Repeat([]byte{1}, 512)
+	dsv, _ := rawToDSV(raw)
+	if err := os.WriteFile(filepath.Join(desmumeDir, "game.dsv"), dsv, 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	copies, err := s.PlanCoreSwitch(1, constants.CoreDeSmuME, constants.CoreMelonDS)
+	if err != nil {
+		t.Fatalf("PlanCoreSwitch failed: %v", err)
+	}
+	want := CoreSaveCopy{FromDir: "DeSmuME", FromName: "game.dsv", ToDir: "melonDS", ToName: "game.sav", Conversion: "dsv-to-sav"}
+	if len(copies) != 1 || copies[0] != want {
+		t.Fatalf("Expected %+v, got %+v", want, copies)
+	}
+
+	if _, err := s.SwitchCoreSaves(1, constants.CoreDeSmuME, constants.CoreMelonDS); err != nil {
+		t.Fatalf("SwitchCoreSaves failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(savesDir, "melonDS", "game.sav"))
+	if err != nil || !bytes.Equal(data, raw) {
+		t.Errorf("Expected the converted save, got %d bytes (err %v)", len(data), err)
+	}
+	if _, err := os.Stat(filepath.Join(desmumeDir, "game.dsv")); err != nil {
+		t.Errorf("Expected the original save to stay: %v", err)
+	}
+
+	// Saves already present for the new core are not offered again.
+	if copies, _ := s.PlanCoreSwitch(1, constants.CoreDeSmuME, constants.CoreMelonDS); len(copies) != 0 {
+		t.Errorf("Expected nothing left to copy, got %+v", copies)
+	}
+}
+
+func TestPlanCoreSwitch_CopiesUnchanged(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "snes", FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	// Saves stored under the core's base name are found too.
+	snes9xDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(snes9xDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(snes9xDir, "game.srm"), []byte("sram"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	copies, err := s.PlanCoreSwitch(1, "snes9x_libretro", "bsnes_libretro")
+	if err != nil {
+		t.Fatalf("PlanCoreSwitch failed: %v", err)
+	}
+	want := CoreSaveCopy{FromDir: "snes9x", FromName: "game.srm", ToDir: "bsnes", ToName: "game.srm"}
+	if len(copies) != 1 || copies[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, copies)
+	}
+	if copies, _ := s.PlanCoreSwitch(1, "snes9x_libretro", "snes9x_libretro"); len(copies) != 0 {
+		t.Errorf("Expected no copies without a switch, got %+v", copies)
+	}
+}
+
+func TestDetectCoreSwitch(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "nds", FullPath: "nds/game.nds"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if from, err := s.DetectCoreSwitch(1, constants.CoreMelonDS); err != nil || from != "" {
+		t.Fatalf("Expected no switch without saves, got %q (err %v)", from, err)
+	}
+
+	savesDir := filepath.Join(tempDir, "nds", "1", "saves")
+	writeSave := func(dir, name string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(savesDir, dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("save"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSave("DeSmuME", "game.dsv", time.Now().Add(-time.Hour))
+
+	if from, _ := s.DetectCoreSwitch(1, constants.CoreMelonDS); from != constants.CoreDeSmuME {
+		t.Errorf("Expected a switch from %s, got %q", constants.CoreDeSmuME, from)
+	}
+	if from, _ := s.DetectCoreSwitch(1, constants.CoreDeSmuME); from != "" {
+		t.Errorf("Expected no switch to the core that wrote the saves, got %q", from)
+	}
+
+	// The core with the most recent saves is the one switched from.
+	writeSave("melonDS", "game.sav", time.Now())
+	if from, _ := s.DetectCoreSwitch(1, constants.CoreNooDS); from != constants.CoreMelonDS {
+		t.Errorf("Expected a switch from %s, got %q", constants.CoreMelonDS, from)
+	}
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"go-romm-sync/library"
+	"go-romm-sync/types"
+	"os"
+	stdsync "sync"
+	"time"
+)
+
+// discCache remembers what was read from a ROM's disc image, such as a PS2 serial,
+// so listing a game's saves does not reopen a large image every time. Entries are
+// dropped when the image changes.
+type discCache struct {
+	mu      stdsync.Mutex
+	entries map[string]discCacheEntry
+}
+
+type discCacheEntry struct {
+	size    int64
+	modTime time.Time
+	value   string
+	err     error
+}
+
+// read returns read(path), reusing the result of an earlier call with the same
+// kind while the file is unchanged.
+func (c *discCache) read(kind, path string, read func(string) (string, error)) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	key := kind + ":" + path
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.value, e.err
+	}
+
+	value, err := read(path)
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]discCacheEntry)
+	}
+	c.entries[key] = discCacheEntry{size: info.Size(), modTime: info.ModTime(), value: value, err: err}
+	c.mu.Unlock()
+	return value, err
+}
+
+// discImage returns the disc image of a downloaded game whose header can be read:
+// the first disc of a multi-disc game's playlist, or else its ROM file.
+func (s *Service) discImage(game *types.Game) string {
+	return library.FirstDisc(s.library.FindRomPath(s.library.GetRomDir(game)))
+}
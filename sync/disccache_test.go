@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiscCache_ReadsOncePerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.iso")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("failed to write iso: %v", err)
+	}
+
+	var c discCache
+	reads := 0
+	read := func(p string) (string, error) {
+		reads++
+		data, err := os.ReadFile(p)
+		return string(data), err
+	}
+
+	for i := 0; i < 2; i++ {
+		if v, err := c.read("test", path, read); err != nil || v != "v1" {
+			t.Fatalf("Expected v1, got %q (err %v)", v, err)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("Expected one read of an unchanged image, got %d", reads)
+	}
+
+	if err := os.WriteFile(path, []byte("v2!"), 0o644); err != nil {
+		t.Fatalf("failed to rewrite iso: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("failed to touch iso: %v", err)
+	}
+	if v, err := c.read("test", path, read); err != nil || v != "v2!" {
+		t.Errorf("Expected the changed image to be read again, got %q (err %v)", v, err)
+	}
+}
@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/utils/crypt"
+	"io"
+)
+
+// currentKeyring returns the keyring of the configured encryption passphrase, or
+// nil when none is set. The keyring is kept while the passphrase is unchanged so
+// its derived keys are reused.
+func (s *Service) currentKeyring() *crypt.Keyring {
+	pass := s.config.GetConfig().EncryptionPassphrase
+	if pass == "" {
+		return nil
+	}
+	s.keyringMu.Lock()
+	defer s.keyringMu.Unlock()
+	if s.keyring == nil || s.keyringPass != pass {
+		s.keyring = crypt.NewKeyring(pass)
+		s.keyringPass = pass
+	}
+	return s.keyring
+}
+
+// encryptAsset wraps the content of an upload in encryption when it is enabled.
+// Uploads fail rather than fall back to plaintext when no passphrase is set.
+func (s *Service) encryptAsset(content io.Reader) (io.Reader, error) {
+	if !s.config.GetConfig().EncryptSaves {
+		return content, nil
+	}
+	keyring := s.currentKeyring()
+	if keyring == nil {
+		return nil, fmt.Errorf("save encryption is enabled but no encryption passphrase is set")
+	}
+	encrypted, err := keyring.Encrypt(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt upload: %w", err)
+	}
+	return encrypted, nil
+}
+
+// decryptAsset returns the plaintext of a downloaded save or state. Unencrypted
+// content is passed through unchanged, whether or not encryption is enabled.
+func (s *Service) decryptAsset(content io.Reader, subDir, filename string) (io.Reader, error) {
+	content, encrypted, err := crypt.Detect(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downloaded %s: %w", subDir, err)
+	}
+	if !encrypted {
+		return content, nil
+	}
+	keyring := s.currentKeyring()
+	if keyring == nil {
+		return nil, fmt.Errorf("%s %s is encrypted; set the encryption passphrase to download it", subDir, filename)
+	}
+	// A wrong passphrase fails here with crypt.ErrWrongKey, before anything is written.
+	plain, err := keyring.Decrypt(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s %s: %w", subDir, filename, err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"go-romm-sync/utils/crypt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedSaves(t *testing.T) {
+	crypt.Iterations = 100_000
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(savePath, []byte("save"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	cm.Config.EncryptSaves = true
+
+	var uploaded []byte
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			if req.Method == http.MethodPost {
+				if f, _, err := req.FormFile("saveFile"); err == nil {
+					uploaded, _ = io.ReadAll(f)
+				}
+				body = []byte("{}")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err == nil {
+		t.Fatal("Expected uploads to fail without a passphrase")
+	}
+
+	cm.Config.EncryptionPassphrase = "correct horse"
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+	if !crypt.IsEncrypted(uploaded) || bytes.Contains(uploaded, []byte("save")) {
+		t.Fatalf("Expected an encrypted upload, got %q", uploaded)
+	}
+
+	download := func() error {
+		return s.saveDownloadedAsset(&game, bytes.NewReader(uploaded), savePath, "snes9x", "game.srm", constants.DirSaves)
+	}
+	if err := os.WriteFile(savePath, []byte("older"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := download(); err != nil {
+		t.Fatalf("saveDownloadedAsset failed: %v", err)
+	}
+	if data, _ := os.ReadFile(savePath); string(data) != "save" {
+		t.Errorf("Expected the decrypted save, got %q", data)
+	}
+
+	cm.Config.EncryptionPassphrase = "wrong"
+	if err := download(); !errors.Is(err, crypt.ErrWrongKey) {
+		t.Errorf("Expected a wrong passphrase error, got %v", err)
+	}
+	cm.Config.EncryptionPassphrase = ""
+	if err := download(); err == nil {
+		t.Error("Expected an encrypted download to fail without a passphrase")
+	}
+	if data, _ := os.ReadFile(savePath); string(data) != "save" {
+		t.Errorf("Expected failed downloads to leave the save alone, got %q", data)
+	}
+
+	// Unencrypted server copies still download with encryption enabled.
+	if err := s.saveDownloadedAsset(&game, bytes.NewReader([]byte("plain")), savePath, "snes9x", "game.srm", constants.DirSaves); err != nil {
+		t.Fatalf("saveDownloadedAsset failed: %v", err)
+	}
+	if data, _ := os.ReadFile(savePath); string(data) != "plain" {
+		t.Errorf("Expected the plain save, got %q", data)
+	}
+}
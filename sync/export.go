@@ -0,0 +1,276 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"go-romm-sync/utils"
+	"go-romm-sync/utils/archive"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// exportManifestName is the file at the root of a save archive listing its assets.
+	exportManifestName = "manifest.json"
+	exportVersion      = 1
+)
+
+// ExportEntry describes one save or state in a save archive.
+type ExportEntry struct {
+	GameID    uint   `json:"game_id"`
+	Title     string `json:"title"`
+	Platform  string `json:"platform"`
+	Type      string `json:"type"` // constants.DirSaves or constants.DirStates
+	Core      string `json:"core"`
+	Name      string `json:"name"`
+	UpdatedAt string `json:"updated_at"`
+	Path      string `json:"path"` // location in the archive; directories are stored zipped
+}
+
+// ExportManifest is the manifest of a save archive.
+type ExportManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt string        `json:"created_at"`
+	Device    string        `json:"device"`
+	Entries   []ExportEntry `json:"entries"`
+}
+
+// RestoreSkip is an archived save or state that was not restored.
+type RestoreSkip struct {
+	Entry  ExportEntry `json:"entry"`
+	Reason string      `json:"reason"`
+}
+
+// RestoreReport summarizes a restore from a save archive.
+type RestoreReport struct {
+	Restored []ExportEntry `json:"restored"`
+	Skipped  []RestoreSkip `json:"skipped"`
+}
+
+// ExportSaves writes every save and state of the downloaded games into a zip
+// archive at destPath, with a manifest describing each of them. Only local files
+// are read, so it works while RomM is unreachable.
+func (s *Service) ExportSaves(destPath string) (ExportManifest, error) {
+	games, err := s.downloadedGames()
+	if err != nil {
+		return ExportManifest{}, err
+	}
+	stageDir, err := os.MkdirTemp("", "romm_export_*")
+	if err != nil {
+		return ExportManifest{}, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stageDir) }()
+
+	manifest := ExportManifest{
+		Version:   exportVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Device:    s.config.DeviceName(),
+	}
+	for i := range games {
+		entries, err := s.stageGameAssets(&games[i], stageDir)
+		if err != nil {
+			return ExportManifest{}, fmt.Errorf("failed to export %s: %w", games[i].Title, err)
+		}
+		manifest.Entries = append(manifest.Entries, entries...)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ExportManifest{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(stageDir, exportManifestName), data, 0o644); err != nil {
+		return ExportManifest{}, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := writeExportArchive(destPath, stageDir); err != nil {
+		return ExportManifest{}, err
+	}
+	s.ui.LogInfof("ExportSaves: Exported %d saves and states to %s", len(manifest.Entries), destPath)
+	return manifest, nil
+}
+
+// stageGameAssets copies the saves and states of a game into the staging directory
+// in the form they are uploaded in.
+func (s *Service) stageGameAssets(game *types.Game, stageDir string) ([]ExportEntry, error) {
+	romDir, biosDir := s.library.GetRomDir(game), s.library.GetBiosDir()
+	platform := getPlatformSlug(game)
+
+	s.exportGameSaves(game)
+	var entries []ExportEntry
+	for _, subDir := range []string{constants.DirSaves, constants.DirStates} {
+		items, err := s.listLocalFiles(game, subDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list local %s: %w", subDir, err)
+		}
+		for _, item := range items {
+			_, src := getLocalAssetPaths(romDir, biosDir, subDir, item.Core, item.Name, platform)
+			e := ExportEntry{
+				GameID:    game.ID,
+				Title:     game.Title,
+				Platform:  platform,
+				Type:      subDir,
+				Core:      item.Core,
+				Name:      item.Name,
+				UpdatedAt: item.UpdatedAt,
+				Path:      path.Join(fmt.Sprint(game.ID), subDir, item.Core, item.Name),
+			}
+			if layoutFor(platform, subDir, item.Core, item.Name).dirAssets {
+				e.Path += ".zip"
+			}
+			if err := stageAsset(src, filepath.Join(stageDir, filepath.FromSlash(e.Path))); err != nil {
+				return nil, fmt.Errorf("failed to stage %s: %w", src, err)
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func stageAsset(src, dst string) error {
+	content, _, err := openAssetContent(src)
+	if err != nil {
+		return err
+	}
+	defer content.Close() //nolint:errcheck
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, content)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeExportArchive zips the staging directory into a temporary file next to
+// destPath and renames it into place, so a failed export never leaves a partial
+// archive behind.
+func writeExportArchive(destPath, stageDir string) error {
+	out, err := os.CreateTemp(filepath.Dir(destPath), "."+filepath.Base(destPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	tmpPath := out.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	_ = out.Chmod(0o644)
+
+	err = archive.ZipDir(out, stageDir)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return nil
+}
+
+// RestoreSaves puts the saves and states of an archive written by ExportSaves back
+// into the library. Games are found by ID, or else by platform and title, so the
+// archive can be restored into a fresh library; each asset is placed where a
+// download of it would go. Current files are backed up before they are replaced,
+// and archived files that fail validation are quarantined instead of restored.
+func (s *Service) RestoreSaves(srcPath string) (RestoreReport, error) {
+	extractDir, err := os.MkdirTemp("", "romm_restore_*")
+	if err != nil {
+		return RestoreReport{}, fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(extractDir) }()
+
+	if err := archive.VerifyZip(srcPath); err != nil {
+		return RestoreReport{}, fmt.Errorf("save archive %s is damaged: %w", srcPath, err)
+	}
+	if _, err := archive.Extract(srcPath, extractDir); err != nil {
+		return RestoreReport{}, fmt.Errorf("failed to extract save archive: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(extractDir, exportManifestName))
+	if err != nil {
+		return RestoreReport{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return RestoreReport{}, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Version > exportVersion {
+		return RestoreReport{}, fmt.Errorf("save archive version %d is not supported", manifest.Version)
+	}
+
+	games, err := s.downloadedGames()
+	if err != nil {
+		return RestoreReport{}, err
+	}
+
+	var report RestoreReport
+	for _, e := range manifest.Entries {
+		game := findExportedGame(games, &e)
+		if game == nil {
+			report.Skipped = append(report.Skipped, RestoreSkip{Entry: e, Reason: "game is not in the library"})
+			continue
+		}
+		if err := s.restoreEntry(game, extractDir, &e); err != nil {
+			s.ui.LogErrorf("RestoreSaves: Failed to restore %s %s/%s of %s: %v", e.Type, e.Core, e.Name, e.Title, err)
+			report.Skipped = append(report.Skipped, RestoreSkip{Entry: e, Reason: err.Error()})
+			continue
+		}
+		report.Restored = append(report.Restored, e)
+	}
+	s.ui.LogInfof("RestoreSaves: Restored %d saves and states, skipped %d", len(report.Restored), len(report.Skipped))
+	return report, nil
+}
+
+// findExportedGame returns the library game an archived asset belongs to. IDs are
+// only trusted on the same platform, since an archive from another RomM server may
+// reuse them for different games.
+func findExportedGame(games []types.Game, e *ExportEntry) *types.Game {
+	for i := range games {
+		if games[i].ID == e.GameID && getPlatformSlug(&games[i]) == e.Platform {
+			return &games[i]
+		}
+	}
+	for i := range games {
+		if getPlatformSlug(&games[i]) == e.Platform && strings.EqualFold(games[i].Title, e.Title) {
+			return &games[i]
+		}
+	}
+	return nil
+}
+
+func (s *Service) restoreEntry(game *types.Game, extractDir string, e *ExportEntry) error {
+	if e.Type != constants.DirSaves && e.Type != constants.DirStates {
+		return fmt.Errorf("unknown asset type %q", e.Type)
+	}
+	src := filepath.Join(extractDir, filepath.FromSlash(e.Path))
+	if !utils.IsSafePath(extractDir, src) {
+		return fmt.Errorf("invalid path traversal detected")
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open archived file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	destPath, err := s.prepareAssetPath(game, e.Core, e.Name, e.Type)
+	if err != nil {
+		return err
+	}
+	if err := s.saveDownloadedAsset(game, f, destPath, e.Core, e.Name, e.Type); err != nil {
+		return err
+	}
+	if err := s.importPS2Save(game, e.Type, e.Core, e.Name, destPath); err != nil {
+		return err
+	}
+	s.setFileTime(destPath, e.UpdatedAt)
+	return nil
+}
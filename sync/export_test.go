@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExportRestoreSaves(t *testing.T) {
+	tempDir := t.TempDir()
+	oldLib := filepath.Join(tempDir, "old")
+	newLib := filepath.Join(tempDir, "new")
+	archivePath := filepath.Join(tempDir, "saves.zip")
+
+	write := func(path, content string, mtime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saveTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	snesDir := writeLibraryGame(t, oldLib, &types.Game{ID: 1, Title: "Snes Game", PlatformSlug: "snes", FullPath: "snes/game.sfc"}, true)
+	pspDir := writeLibraryGame(t, oldLib, &types.Game{ID: 2, Title: "Psp Game", PlatformSlug: "psp", FullPath: "psp/game.iso"}, true)
+	write(filepath.Join(snesDir, "saves", "Snes9x", "game.srm"), "snes save", saveTime)
+	write(filepath.Join(snesDir, "states", "Snes9x", "game.state"), "snes state", saveTime)
+	write(filepath.Join(pspDir, "saves", "PPSSPP", "PSP", "SAVEDATA", "ULUS00001", "DATA.BIN"), "psp save", saveTime)
+
+	lib, romm, cm := setupServices(oldLib, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+	manifest, err := s.ExportSaves(archivePath)
+	if err != nil {
+		t.Fatalf("ExportSaves failed: %v", err)
+	}
+	if len(manifest.Entries) != 3 {
+		t.Fatalf("Expected 3 exported assets, got %+v", manifest.Entries)
+	}
+	for _, e := range manifest.Entries {
+		if e.Title == "" || e.Platform == "" || e.UpdatedAt == "" {
+			t.Errorf("Expected title, platform and timestamp in %+v", e)
+		}
+	}
+
+	// The fresh library has the SNES game under another path and ID, an existing
+	// state that gets backed up, and no PSP game.
+	newSnesDir := writeLibraryGame(t, newLib, &types.Game{ID: 7, Title: "Snes Game", PlatformSlug: "snes", FullPath: "Nintendo/snes.sfc"}, true)
+	write(filepath.Join(newSnesDir, "states", "Snes9x", "game.state"), "newer state", time.Now())
+
+	lib, romm, cm = setupServices(newLib, nil, nil)
+	s = New(cm, lib, romm, &MockUIProvider{})
+	report, err := s.RestoreSaves(archivePath)
+	if err != nil {
+		t.Fatalf("RestoreSaves failed: %v", err)
+	}
+	if len(report.Restored) != 2 || len(report.Skipped) != 1 || report.Skipped[0].Entry.GameID != 2 {
+		t.Fatalf("Unexpected restore report: %+v", report)
+	}
+
+	savePath := filepath.Join(newSnesDir, "saves", "Snes9x", "game.srm")
+	if data, _ := os.ReadFile(savePath); string(data) != "snes save" {
+		t.Errorf("Expected the restored save, got %q", data)
+	}
+	if info, err := os.Stat(savePath); err != nil || !info.ModTime().Equal(saveTime) {
+		t.Errorf("Expected the exported modification time to be restored, got %v", info.ModTime())
+	}
+	if data, _ := os.ReadFile(filepath.Join(newSnesDir, "states", "Snes9x", "game.state")); string(data) != "snes state" {
+		t.Errorf("Expected the restored state, got %q", data)
+	}
+	versions, _ := s.ListBackups(7)
+	if len(versions) != 1 {
+		t.Errorf("Expected the replaced state to be backed up, got %+v", versions)
+	}
+
+	// Directory assets survive the round trip.
+	if err := os.RemoveAll(filepath.Join(newLib, "Nintendo")); err != nil {
+		t.Fatal(err)
+	}
+	newPspDir := writeLibraryGame(t, newLib, &types.Game{ID: 2, Title: "Psp Game", PlatformSlug: "psp", FullPath: "psp/game.iso"}, true)
+	report, err = s.RestoreSaves(archivePath)
+	if err != nil {
+		t.Fatalf("RestoreSaves failed: %v", err)
+	}
+	if len(report.Restored) != 1 {
+		t.Fatalf("Expected the PSP save to be restored, got %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(newPspDir, "saves", "PPSSPP", "PSP", "SAVEDATA", "ULUS00001", "DATA.BIN")); string(data) != "psp save" {
+		t.Errorf("Expected the restored PSP save, got %q", data)
+	}
+
+	if _, err := s.RestoreSaves(savePath); err == nil {
+		t.Error("Expected an error for a file that is not a save archive")
+	}
+}
+
+func TestFindExportedGame(t *testing.T) {
+	games := []types.Game{
+		{ID: 1, PlatformSlug: "gba", Title: "Other Game"},
+		{ID: 2, PlatformSlug: "snes", Title: "Super Game"},
+	}
+
+	if g := findExportedGame(games, &ExportEntry{GameID: 2, Platform: "snes", Title: "Renamed"}); g == nil || g.ID != 2 {
+		t.Errorf("Expected a match by ID on the same platform, got %+v", g)
+	}
+	// The ID belongs to a game of another platform, so the title decides.
+	if g := findExportedGame(games, &ExportEntry{GameID: 1, Platform: "snes", Title: "super game"}); g == nil || g.ID != 2 {
+		t.Errorf("Expected a match by title, got %+v", g)
+	}
+	if g := findExportedGame(games, &ExportEntry{GameID: 1, Platform: "snes", Title: "Unknown"}); g != nil {
+		t.Errorf("Expected no match for an ID of another platform, got %+v", g)
+	}
+}
@@ -0,0 +1,226 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/retroarch"
+	"go-romm-sync/types"
+	"go-romm-sync/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ImportedAsset is a RetroArch save or state copied into a game's folder.
+type ImportedAsset struct {
+	GameID   uint   `json:"game_id"`
+	Type     string `json:"type"` // constants.DirSaves or constants.DirStates
+	Core     string `json:"core"`
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Uploaded bool   `json:"uploaded"`
+	Error    string `json:"error,omitempty"` // upload failure
+}
+
+// ImportSkip is a RetroArch file that was not imported.
+type ImportSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport summarizes an import of RetroArch saves and states.
+type ImportReport struct {
+	Imported  []ImportedAsset `json:"imported"`
+	Unmatched []ImportSkip    `json:"unmatched"`
+}
+
+// ImportRetroArchSaves copies the saves and states found in RetroArch's own save
+// and state directories into the folders of the downloaded games they belong to,
+// matching file names against ROM file names. Files in a subfolder named after a
+// known core are imported for that core; other files for the platform's last used
+// or default core. Each file is placed where a download of it would go.
+// Existing files are never overwritten. With upload set, imported saves and states
+// are uploaded to RomM afterwards.
+func (s *Service) ImportRetroArchSaves(savesDir, statesDir string, upload bool) (ImportReport, error) {
+	games, err := s.downloadedGames()
+	if err != nil {
+		return ImportReport{}, err
+	}
+	byRomName := make(map[string]*types.Game)
+	for i := range games {
+		romPath := s.library.FindRomPath(s.library.GetRomDir(&games[i]))
+		name := strings.TrimSuffix(filepath.Base(romPath), filepath.Ext(romPath))
+		byRomName[strings.ToLower(name)] = &games[i]
+	}
+
+	libPath := s.config.GetConfig().LibraryPath
+	var report ImportReport
+	for _, src := range []struct{ subDir, dir string }{
+		{constants.DirSaves, savesDir},
+		{constants.DirStates, statesDir},
+	} {
+		if src.dir == "" {
+			continue
+		}
+		if utils.IsSafePath(libPath, src.dir) {
+			return report, fmt.Errorf("RetroArch %s directory %s is inside the library", src.subDir, src.dir)
+		}
+		if err := s.importDir(src.subDir, src.dir, byRomName, &report); err != nil {
+			return report, err
+		}
+	}
+
+	if upload {
+		for i := range report.Imported {
+			a := &report.Imported[i]
+			if err := s.uploadServerAsset(a.GameID, a.Core, a.Name, a.Type); err != nil {
+				s.ui.LogErrorf("ImportRetroArchSaves: Failed to upload %s %s/%s: %v", a.Type, a.Core, a.Name, err)
+				a.Error = err.Error()
+				continue
+			}
+			a.Uploaded = true
+		}
+	}
+	s.ui.LogInfof("ImportRetroArchSaves: Imported %d files, %d unmatched", len(report.Imported), len(report.Unmatched))
+	return report, nil
+}
+
+// importDir imports the files directly in dir and in its core subfolders, including
+// RetroArch's per-content-directory folders below them.
+func (s *Service) importDir(subDir, dir string, byRomName map[string]*types.Game, report *ImportReport) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if len(parts) > 2 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		game := matchRomName(d.Name(), byRomName)
+		if game == nil {
+			report.Unmatched = append(report.Unmatched, ImportSkip{Path: path, Reason: "no downloaded game with this name"})
+			return nil
+		}
+		// Subfolders that are not named after a core, such as RetroArch's
+		// per-content-directory folders, fall back to the default core.
+		core := parts[0]
+		if len(parts) == 1 || !knownCoreDir(core) {
+			core = s.defaultCoreDir(game)
+		}
+		if core == "" {
+			report.Unmatched = append(report.Unmatched, ImportSkip{Path: path, Reason: "no core known for the game's platform"})
+			return nil
+		}
+
+		// The file goes where a download of it would, following the core's layout.
+		l := layoutFor(getPlatformSlug(game), subDir, core, d.Name())
+		if l.dirAssets {
+			report.Unmatched = append(report.Unmatched, ImportSkip{Path: path, Reason: "the core keeps its saves in folders"})
+			return nil
+		}
+		dest, err := s.assetDestPath(game, core, d.Name(), subDir)
+		if err != nil {
+			report.Unmatched = append(report.Unmatched, ImportSkip{Path: path, Reason: err.Error()})
+			return nil
+		}
+		core = l.resolveCore(normalizeCore(core), s.gamePaths(game, subDir))
+		destDir := filepath.Dir(dest)
+		if _, err := os.Stat(dest); err == nil {
+			report.Unmatched = append(report.Unmatched, ImportSkip{Path: path, Reason: "already in the library"})
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(destDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", destDir, err)
+		}
+		if err := copyFile(path, dest, info); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", path, err)
+		}
+		report.Imported = append(report.Imported, ImportedAsset{GameID: game.ID, Type: subDir, Core: core, Name: d.Name(), Source: path})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s from %s: %w", subDir, dir, err)
+	}
+	return nil
+}
+
+// knownCoreDir reports whether a RetroArch save or state subfolder is named after a
+// known core: a save folder of a core in retroarch.PlatformCoreMap or
+// retroarch.ExtCoreMap, or a core handled by a save layout.
+func knownCoreDir(name string) bool {
+	for _, cores := range retroarch.PlatformCoreMap {
+		for _, core := range cores {
+			if slices.Contains(coreSaveDirCandidates(core), name) {
+				return true
+			}
+		}
+	}
+	for _, cores := range retroarch.ExtCoreMap {
+		for _, core := range cores {
+			if slices.Contains(coreSaveDirCandidates(core), name) {
+				return true
+			}
+		}
+	}
+	for i := range saveLayouts {
+		if slices.Contains(saveLayouts[i].cores, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRomName finds the game whose ROM file name is a prefix of a save or state
+// name, stripping one extension at a time: "Game (USA).state1.png" is tried as
+// "Game (USA).state1" and then "Game (USA)".
+func matchRomName(name string, byRomName map[string]*types.Game) *types.Game {
+	for stem := strings.ToLower(name); ; {
+		ext := filepath.Ext(stem)
+		if ext == "" {
+			return nil
+		}
+		stem = strings.TrimSuffix(stem, ext)
+		if game, ok := byRomName[stem]; ok {
+			return game
+		}
+	}
+}
+
+// defaultCoreDir returns the save folder of the core last used for the game's
+// platform, or else of its default core.
+func (s *Service) defaultCoreDir(game *types.Game) string {
+	platform := getPlatformSlug(game)
+	core := s.config.GetConfig().LastUsedCores[platform]
+	if core == "" {
+		if cores := retroarch.GetCoresForPlatform(platform); len(cores) > 0 {
+			core = cores[0]
+		}
+	}
+	if core == "" {
+		return ""
+	}
+	return coreSaveDirCandidates(core)[0]
+}
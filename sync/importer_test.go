@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"bytes"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportRetroArchSaves(t *testing.T) {
+	tempDir := t.TempDir()
+	libDir := filepath.Join(tempDir, "library")
+	raDir := filepath.Join(tempDir, "retroarch")
+
+	romDir := writeLibraryGame(t, libDir, &types.Game{ID: 1, PlatformSlug: "snes", FullPath: "snes/Super Game (USA).sfc"}, true)
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	savesDir := filepath.Join(raDir, "saves")
+	statesDir := filepath.Join(raDir, "states")
+	write(filepath.Join(savesDir, "Super Game (USA).srm"), "loose save")
+	write(filepath.Join(savesDir, "Snes9x", "Super Game (USA).srm"), "sorted save")
+	write(filepath.Join(savesDir, "Unknown Game.srm"), "orphan")
+	write(filepath.Join(statesDir, "bsnes", "Super Game (USA).state1"), "state")
+	// A per-content-directory folder is not a core.
+	write(filepath.Join(statesDir, "snes roms", "Super Game (USA).state2"), "content dir state")
+
+	lib, romm, cm := setupServices(libDir, nil, nil)
+	var uploads []string
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := []byte("[]")
+			if req.Method == http.MethodPost {
+				uploads = append(uploads, req.URL.Path)
+				body = []byte("{}")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	report, err := s.ImportRetroArchSaves(savesDir, statesDir, true)
+	if err != nil {
+		t.Fatalf("ImportRetroArchSaves failed: %v", err)
+	}
+
+	// The loose save goes to the default core's folder, which the sorted save
+	// already claimed, so only one of them is imported.
+	if len(report.Imported) != 3 {
+		t.Fatalf("Expected 3 imported files, got %+v", report.Imported)
+	}
+	if data, _ := os.ReadFile(filepath.Join(romDir, "saves", "Snes9x", "Super Game (USA).srm")); len(data) == 0 {
+		t.Error("Expected the save in the Snes9x folder")
+	}
+	if data, _ := os.ReadFile(filepath.Join(romDir, "states", "bsnes", "Super Game (USA).state1")); string(data) != "state" {
+		t.Errorf("Expected the state in the bsnes folder, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(romDir, "states", "Snes9x", "Super Game (USA).state2")); string(data) != "content dir state" {
+		t.Errorf("Expected the content directory state in the default core's folder, got %q", data)
+	}
+	for _, a := range report.Imported {
+		if a.GameID != 1 || !a.Uploaded {
+			t.Errorf("Expected an uploaded asset of game 1, got %+v", a)
+		}
+	}
+	if len(uploads) != 3 {
+		t.Errorf("Expected 3 uploads, got %v", uploads)
+	}
+
+	reasons := map[string]string{}
+	for _, u := range report.Unmatched {
+		reasons[filepath.Base(u.Path)] = u.Reason
+	}
+	if reasons["Unknown Game.srm"] == "" || reasons["Super Game (USA).srm"] != "already in the library" || len(reasons) != 2 {
+		t.Errorf("Unexpected unmatched files: %+v", report.Unmatched)
+	}
+
+	if _, err := s.ImportRetroArchSaves(filepath.Join(libDir, constants.DirSaves), "", false); err == nil {
+		t.Error("Expected a RetroArch directory inside the library to be refused")
+	}
+}
+
+func TestImportRetroArchSaves_Layouts(t *testing.T) {
+	tempDir := t.TempDir()
+	libDir := filepath.Join(tempDir, "library")
+	savesDir := filepath.Join(tempDir, "retroarch", "saves")
+
+	writeLibraryGame(t, libDir, &types.Game{ID: 1, PlatformSlug: "ps2", FullPath: "ps2/Paladin Quest.iso"}, true)
+	writeLibraryGame(t, libDir, &types.Game{ID: 2, PlatformSlug: platformPSP, FullPath: "psp/Puzzle Quest.iso"}, true)
+	gcDir := writeLibraryGame(t, libDir, &types.Game{ID: 3, PlatformSlug: "ngc", FullPath: "ngc/Racer.iso", Regions: []string{"Europe"}}, true)
+
+	for _, rel := range []string{
+		filepath.Join(corePCSX2, "Paladin Quest.ps2"),
+		filepath.Join(corePPSSPP, "Puzzle Quest.sav"),
+		filepath.Join("Card A", "Racer.gci"),
+	} {
+		path := filepath.Join(savesDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("save"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib, romm, cm := setupServices(libDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+	report, err := s.ImportRetroArchSaves(savesDir, "", false)
+	if err != nil {
+		t.Fatalf("ImportRetroArchSaves failed: %v", err)
+	}
+
+	// Files are placed where downloads of them go: PCSX2 memory cards in the BIOS
+	// directory and GameCube cards in the folder of the game's region.
+	for _, path := range []string{
+		filepath.Join(lib.GetBiosDir(), "pcsx2", "memcards", "Paladin Quest.ps2"),
+		filepath.Join(gcDir, "saves", coreDolphin, "User", "GC", regionEUR, "Card A", "Racer.gci"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected an imported save at %s: %v", path, err)
+		}
+	}
+	if len(report.Imported) != 2 || report.Imported[0].Core != dolphinCardCore(regionEUR, "Card A") {
+		t.Errorf("Unexpected imported files: %+v", report.Imported)
+	}
+
+	// PPSSPP keeps a folder per save, which a single file cannot be imported into.
+	if len(report.Unmatched) != 1 || filepath.Base(report.Unmatched[0].Path) != "Puzzle Quest.sav" {
+		t.Errorf("Expected the PPSSPP file to be skipped, got %+v", report.Unmatched)
+	}
+}
@@ -0,0 +1,167 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-romm-sync/types"
+	"go-romm-sync/utils/archive"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalFileName is the per-game file, stored next to metadata.json, that
+// records the last synced version of each save and state.
+const journalFileName = ".sync-journal.json"
+
+// JournalEntry records the version of a save or state that was last known to be
+// identical locally and on the server.
+type JournalEntry struct {
+	Hash            string `json:"hash"` // SHA-256 of the local content at sync time
+	Size            int64  `json:"size"`
+	ModTime         string `json:"mod_time"`
+	ServerID        uint   `json:"server_id"`
+	ServerUpdatedAt string `json:"server_updated_at"`
+	SyncedAt        string `json:"synced_at"`
+}
+
+type syncJournal struct {
+	Entries map[string]JournalEntry `json:"entries"`
+}
+
+func journalKey(subDir, core, name string) string {
+	return subDir + "/" + assetKey(core, name)
+}
+
+func (s *Service) journalPath(game *types.Game) string {
+	return filepath.Join(s.library.GetRomDir(game), journalFileName)
+}
+
+func (s *Service) loadJournal(game *types.Game) (*syncJournal, error) {
+	j := &syncJournal{Entries: make(map[string]JournalEntry)}
+	data, err := os.ReadFile(s.journalPath(game))
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync journal: %w", err)
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("failed to parse sync journal: %w", err)
+	}
+	if j.Entries == nil {
+		j.Entries = make(map[string]JournalEntry)
+	}
+	return j, nil
+}
+
+func (s *Service) saveJournal(game *types.Game, j *syncJournal) error {
+	path := s.journalPath(game)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync journal: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write sync journal: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// recordSync stores the current content of a local asset as the version that
+// matches the given server record. When the server record is unknown, e.g. because
+// listing the server copies failed after an upload, the previously recorded server
+// version is kept, and nothing is recorded for an asset without one: a made-up
+// server version would turn the next local change into a conflict.
+func (s *Service) recordSync(game *types.Game, subDir, core, name, localPath string, remote *types.ServerAsset) {
+	hash, st, err := hashAsset(localPath)
+	if err != nil {
+		s.ui.LogErrorf("recordSync: Failed to hash %s: %v", localPath, err)
+		return
+	}
+
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	j, err := s.loadJournal(game)
+	if err != nil {
+		s.ui.LogErrorf("recordSync: %v", err)
+		return
+	}
+	key := journalKey(subDir, core, name)
+	serverID, serverUpdatedAt := remote.ID, remote.UpdatedAt
+	if serverUpdatedAt == "" {
+		prev, ok := j.Entries[key]
+		if !ok {
+			s.ui.LogInfof("recordSync: Server version of %s unknown, not recording it", key)
+			return
+		}
+		serverID, serverUpdatedAt = prev.ServerID, prev.ServerUpdatedAt
+	}
+	j.Entries[key] = JournalEntry{
+		Hash:            hash,
+		Size:            st.size,
+		ModTime:         st.modTime.UTC().Format(time.RFC3339Nano),
+		ServerID:        serverID,
+		ServerUpdatedAt: serverUpdatedAt,
+		SyncedAt:        time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.saveJournal(game, j); err != nil {
+		s.ui.LogErrorf("recordSync: %v", err)
+	}
+}
+
+// localAssetHash returns the content hash of a local asset. When the file still has
+// the size and modification time recorded in the journal the stored hash is reused.
+func localAssetHash(path string, entry *JournalEntry) (string, error) {
+	if entry != nil && entry.Hash != "" {
+		if st, ok := statAsset(path); ok && st.size == entry.Size && st.modTime.UTC().Format(time.RFC3339Nano) == entry.ModTime {
+			return entry.Hash, nil
+		}
+	}
+	hash, _, err := hashAsset(path)
+	return hash, err
+}
+
+// hashAsset returns the SHA-256 of a file, or the content hash of a directory asset
+// (see archive.HashDir), together with its size and modification time.
+func hashAsset(path string) (string, assetStat, error) {
+	st, ok := statAsset(path)
+	if !ok {
+		return "", assetStat{}, fmt.Errorf("asset not found: %s", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", assetStat{}, err
+	}
+	if !info.IsDir() {
+		h := sha256.New()
+		if err := copyFileTo(h, path); err != nil {
+			return "", assetStat{}, err
+		}
+		return hex.EncodeToString(h.Sum(nil)), st, nil
+	}
+
+	hash, err := archive.HashDir(path)
+	if err != nil {
+		return "", assetStat{}, err
+	}
+	return hash, st, nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashAsset_Directory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Card A")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "save.gci"), []byte("one"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	first, _, err := hashAsset(dir)
+	if err != nil {
+		t.Fatalf("hashAsset failed: %v", err)
+	}
+	again, _, _ := hashAsset(dir)
+	if first != again {
+		t.Errorf("Expected stable hash, got %q and %q", first, again)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "save.gci"), []byte("two"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	changed, _, _ := hashAsset(dir)
+	if changed == first {
+		t.Error("Expected hash to change with content")
+	}
+}
+
+func TestRecordSync_RoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	path := filepath.Join(tempDir, "game.srm")
+	if err := os.WriteFile(path, []byte("progress"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	s.recordSync(&game, "saves", "snes9x", "game.srm", path, &types.ServerAsset{ID: 5, UpdatedAt: "2024-03-01T09:00:00Z"})
+
+	j, err := s.loadJournal(&game)
+	if err != nil {
+		t.Fatalf("loadJournal failed: %v", err)
+	}
+	entry, ok := j.Entries[journalKey("saves", "snes9x", "game.srm")]
+	if !ok {
+		t.Fatalf("Expected journal entry, got %+v", j.Entries)
+	}
+	if entry.ServerID != 5 || entry.Size != int64(len("progress")) {
+		t.Errorf("Unexpected journal entry: %+v", entry)
+	}
+
+	hash, err := localAssetHash(path, &entry)
+	if err != nil || hash != entry.Hash {
+		t.Errorf("Expected unchanged file to reuse hash %q, got %q (err %v)", entry.Hash, hash, err)
+	}
+}
+
+func TestRecordSync_UnknownServerVersion(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	path := filepath.Join(tempDir, "game.srm")
+	if err := os.WriteFile(path, []byte("progress"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	key := journalKey("saves", "snes9x", "game.srm")
+
+	s.recordSync(&game, "saves", "snes9x", "game.srm", path, &types.ServerAsset{})
+	if j, _ := s.loadJournal(&game); len(j.Entries) != 0 {
+		t.Fatalf("Expected nothing recorded without a server version, got %+v", j.Entries)
+	}
+
+	s.recordSync(&game, "saves", "snes9x", "game.srm", path, &types.ServerAsset{ID: 5, UpdatedAt: "2024-03-01T09:00:00Z"})
+	if err := os.WriteFile(path, []byte("more progress"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	s.recordSync(&game, "saves", "snes9x", "game.srm", path, &types.ServerAsset{})
+
+	j, _ := s.loadJournal(&game)
+	entry := j.Entries[key]
+	if entry.ServerID != 5 || entry.ServerUpdatedAt != "2024-03-01T09:00:00Z" || entry.Size != int64(len("more progress")) {
+		t.Errorf("Expected the new content with the previous server version, got %+v", entry)
+	}
+}
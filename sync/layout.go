@@ -0,0 +1,394 @@
+package sync
+
+import (
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// saveLayout describes where an emulator core keeps its saves or states, and how
+// RomM's emulator field maps onto that location. Assets that no layout claims
+// live in the default flat layout: <romDir>/<subDir>/<core>/<file>.
+type saveLayout struct {
+	name string
+	// platform restricts the layout to some platform slugs; nil matches all.
+	platform func(slug string) bool
+	// subDirs restricts the layout to saves and/or states; empty matches both.
+	subDirs []string
+	// cores lists the local core names and RomM emulator values handled, compared
+	// by their last path element.
+	cores []string
+	// assetName restricts the layout to a single fixed asset.
+	assetName string
+	// pattern restricts the layout to file names matching a filepath.Match pattern.
+	pattern string
+	// dirAssets marks assets that are directories and are zipped for upload.
+	dirAssets bool
+	// inBios marks layouts stored under the BIOS directory instead of the game.
+	inBios bool
+	// localCore maps a RomM emulator value to the core reported for local assets.
+	// nil keeps the value unchanged.
+	localCore func(core string, p layoutPaths) string
+	// keyCore maps a core to the one used to pair local and server assets.
+	// nil uses localCore without game context.
+	keyCore func(core string) string
+	// dir returns the directory holding the assets. nil uses <romDir>/<subDir>/<core>.
+	dir func(p layoutPaths) string
+	// scanCores lists the cores enumerated, after localCore, when listing a game's
+	// assets. Layouts without scanCores only change how paths are resolved.
+	scanCores []string
+	// fallbackCore returns the local core listed instead of a scan core that holds
+	// no assets, or "" for none.
+	fallbackCore func(core string, p layoutPaths) string
+	// enabled reports whether the layout is synced for a game; nil means always.
+	// Disabled layouts still resolve paths but are neither listed nor downloaded.
+	enabled func(p layoutPaths) bool
+}
+
+// layoutPaths carries the locations a layout resolves its directory from.
+type layoutPaths struct {
+	romDir  string
+	biosDir string
+	subDir  string
+	core    string
+	region  string // Dolphin GameCube memory card region; see gameCubeRegion
+	serial  string // PS2 game serial; see ps2Serial
+}
+
+const (
+	coreFlycast   = "flycast_libretro"
+	coreMupenNext = "Mupen64Plus-Next"
+)
+
+// dolphinCardCore returns the local core path of a Dolphin GameCube memory card.
+func dolphinCardCore(region, card string) string {
+	return path.Join(coreDolphin, "User", "GC", region, card)
+}
+
+// saveLayouts is the registry of non-default layouts. The first match wins.
+var saveLayouts = []saveLayout{
+	{
+		// Per-game saves exported from the shared PCSX2 memory card; see ps2.go.
+		name:      layoutPS2Saves,
+		platform:  onPlatforms("ps2"),
+		subDirs:   []string{constants.DirSaves},
+		cores:     []string{corePCSX2},
+		pattern:   "*.psu",
+		scanCores: []string{corePCSX2},
+	},
+	{
+		// Whole memory cards are only synced when the game's saves cannot be
+		// picked out of them.
+		name:      "pcsx2-memcards",
+		platform:  onPlatforms("ps2"),
+		subDirs:   []string{constants.DirSaves},
+		cores:     []string{corePCSX2},
+		inBios:    true,
+		dir:       func(p layoutPaths) string { return filepath.Join(p.biosDir, "pcsx2", "memcards") },
+		scanCores: []string{corePCSX2},
+		enabled:   func(p layoutPaths) bool { return p.serial == "" },
+	},
+	{
+		name:      "ppsspp-savedata",
+		platform:  onPlatforms(platformPSP),
+		subDirs:   []string{constants.DirSaves},
+		cores:     []string{corePPSSPP, corePPSSPP_LR},
+		dirAssets: true,
+		dir: func(p layoutPaths) string {
+			return filepath.Join(p.romDir, p.subDir, p.core, "PSP", "SAVEDATA")
+		},
+		scanCores: []string{corePPSSPP, corePPSSPP_LR},
+	},
+	{
+		name:      "azahar",
+		cores:     []string{constants.CoreAzahar},
+		assetName: azaharDirName,
+		dirAssets: true,
+		dir:       func(p layoutPaths) string { return filepath.Join(p.romDir, p.subDir) },
+		scanCores: []string{constants.CoreAzahar},
+	},
+	{
+		name:      "dolphin-wii",
+		platform:  onPlatforms(platformWii),
+		cores:     []string{coreDolphin},
+		assetName: wiiDirName,
+		dirAssets: true,
+		dir:       func(p layoutPaths) string { return filepath.Join(p.romDir, p.subDir, coreDolphin, "User") },
+		scanCores: []string{coreDolphin},
+	},
+	{
+		// RomM stores GameCube memory card saves with emulator "Card A" or "Card B";
+		// the dolphin-emu core expects them under User/GC/<region>/<card>, where the
+		// region follows the game's disc. Cards that still sit in the USA folder
+		// Dolphin defaults to are listed while the region folder has none, and local
+		// cores that already name a region folder are kept, so misplaced cards are
+		// still synced until they are migrated.
+		name:     "dolphin-gc",
+		platform: func(slug string) bool { return slug != platformWii },
+		cores:    []string{"Card A", "Card B"},
+		localCore: func(core string, p layoutPaths) string {
+			if strings.Contains(core, "/GC/") {
+				return core
+			}
+			region := p.region
+			if region == "" {
+				region = regionUSA
+			}
+			return dolphinCardCore(region, path.Base(core))
+		},
+		// A game has a single region, so cards are paired regardless of the folder.
+		keyCore:   func(core string) string { return dolphinCardCore("", path.Base(core)) },
+		scanCores: []string{"Card A", "Card B"},
+		fallbackCore: func(core string, p layoutPaths) string {
+			if p.region == "" || p.region == regionUSA {
+				return ""
+			}
+			return dolphinCardCore(regionUSA, path.Base(core))
+		},
+	},
+	{
+		// Flycast keeps its shared VMUs in the system directory; per-game VMUs
+		// follow the default layout.
+		name:      "flycast-vmu",
+		platform:  onPlatforms("dc"),
+		subDirs:   []string{constants.DirSaves},
+		cores:     []string{coreFlycast, "Flycast"},
+		pattern:   "vmu_save_*.bin",
+		inBios:    true,
+		dir:       func(p layoutPaths) string { return filepath.Join(p.biosDir, "dc") },
+		scanCores: []string{coreFlycast},
+	},
+	// RetroArch sorts saves and states into folders named after the core's display
+	// name, while RomM may hold the library or standalone emulator name.
+	coreNameLayout("mupen64plus", coreMupenNext, "mupen64plus_next_libretro", "mupen64plus_next", "mupen64plus"),
+	coreNameLayout("parallel-n64", "ParaLLEl N64", "parallel_n64_libretro"),
+	coreNameLayout("snes9x", "Snes9x", "snes9x_libretro"),
+	coreNameLayout("bsnes", "bsnes", "bsnes_libretro"),
+	coreNameLayout("gambatte", "Gambatte", "gambatte_libretro"),
+	coreNameLayout("mgba", "mGBA", "mgba_libretro"),
+	coreNameLayout("sameboy", "SameBoy", "sameboy_libretro"),
+	coreNameLayout("vba-next", "VBA Next", "vba_next_libretro"),
+	coreNameLayout("nestopia", "Nestopia", "nestopia_libretro"),
+	coreNameLayout("fceumm", "FCEUmm", "fceumm_libretro"),
+	coreNameLayout("mesen", "Mesen", "mesen_libretro"),
+	coreNameLayout("genesis-plus-gx", "Genesis Plus GX", "genesis_plus_gx_libretro"),
+	coreNameLayout("picodrive", "PicoDrive", "picodrive_libretro"),
+	coreNameLayout("blastem", "BlastEm", "blastem_libretro"),
+	coreNameLayout("pcsx-rearmed", "PCSX-ReARMed", "pcsx_rearmed_libretro"),
+	coreNameLayout("beetle-psx", "Beetle PSX", "beetle_psx_libretro"),
+	coreNameLayout("desmume", "DeSmuME", constants.CoreDeSmuME),
+	coreNameLayout("melonds", "melonDS", constants.CoreMelonDS),
+	coreNameLayout("melonds-ds", "melonDS DS", constants.CoreMelonDSDS),
+	coreNameLayout("noods", "NooDS", constants.CoreNooDS),
+}
+
+// coreNameLayout stores the assets of a core in its RetroArch folder dir, which
+// the other names of the core resolve to.
+func coreNameLayout(name, dir string, cores ...string) saveLayout {
+	return saveLayout{
+		name:      name,
+		cores:     append([]string{dir}, cores...),
+		localCore: func(string, layoutPaths) string { return dir },
+	}
+}
+
+func onPlatforms(slugs ...string) func(string) bool {
+	return func(slug string) bool {
+		for _, s := range slugs {
+			if s == slug {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// normalizeCore converts Windows separators, which RomM keeps for emulator
+// values uploaded from Windows, so the last path element can be compared.
+func normalizeCore(core string) string {
+	return strings.ReplaceAll(core, "\\", "/")
+}
+
+func (l *saveLayout) handlesCore(core string) bool {
+	base := path.Base(normalizeCore(core))
+	for _, c := range l.cores {
+		if c == base {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *saveLayout) appliesTo(platform, subDir string) bool {
+	if l.platform != nil && !l.platform(platform) {
+		return false
+	}
+	if len(l.subDirs) == 0 {
+		return true
+	}
+	for _, d := range l.subDirs {
+		if d == subDir {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *saveLayout) matches(platform, subDir, core, filename string) bool {
+	if !l.appliesTo(platform, subDir) || !l.handlesCore(core) {
+		return false
+	}
+	if l.assetName != "" && filename != l.assetName {
+		return false
+	}
+	if l.pattern != "" {
+		if ok, _ := filepath.Match(l.pattern, filename); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultLayout stores assets at <romDir>/<subDir>/<core>/<file>.
+var defaultLayout = saveLayout{name: "default"}
+
+func (l *saveLayout) isEnabled(p layoutPaths) bool {
+	return l.enabled == nil || l.enabled(p)
+}
+
+// layoutFor returns the layout that stores an asset.
+func layoutFor(platform, subDir, core, filename string) *saveLayout {
+	for i := range saveLayouts {
+		if saveLayouts[i].matches(platform, subDir, core, filename) {
+			return &saveLayouts[i]
+		}
+	}
+	return &defaultLayout
+}
+
+// resolveCore returns the local core for a core or RomM emulator value.
+func (l *saveLayout) resolveCore(core string, p layoutPaths) string {
+	core = normalizeCore(core)
+	if l.localCore != nil {
+		return l.localCore(core, p)
+	}
+	return core
+}
+
+// assetDir returns the directory holding the layout's assets for a local core.
+func (l *saveLayout) assetDir(p layoutPaths) string {
+	if l.dir != nil {
+		return l.dir(p)
+	}
+	return filepath.Join(p.romDir, p.subDir, filepath.FromSlash(p.core))
+}
+
+// SharedSaveDirs returns the directories under the BIOS directory where emulators
+// keep saves shared by all games, such as the PCSX2 memory cards.
+func SharedSaveDirs(biosDir string) []string {
+	var dirs []string
+	for i := range saveLayouts {
+		l := &saveLayouts[i]
+		if !l.inBios || l.dir == nil {
+			continue
+		}
+		if dir := l.dir(layoutPaths{biosDir: biosDir}); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// canonicalCore maps a core or RomM emulator value to the local core it is
+// stored under, independent of platform, so server and local assets can be paired.
+func canonicalCore(core string) string {
+	for i := range saveLayouts {
+		l := &saveLayouts[i]
+		if !l.handlesCore(core) {
+			continue
+		}
+		if l.keyCore != nil {
+			return l.keyCore(normalizeCore(core))
+		}
+		if l.localCore != nil {
+			return l.resolveCore(core, layoutPaths{})
+		}
+	}
+	return normalizeCore(core)
+}
+
+// scanAssets lists the assets of every scan core of the layout. The returned claims
+// are the top-level entries of <romDir>/<subDir> the layout owns, which are
+// excluded from the default scan.
+func (l *saveLayout) scanAssets(p layoutPaths) (items []types.FileItem, claims []string) {
+	subDirPath := filepath.Join(p.romDir, p.subDir)
+	for _, scanCore := range l.scanCores {
+		core := l.resolveCore(scanCore, p)
+		p.core = core
+		dir := l.assetDir(p)
+		if l.fallbackCore != nil && len(l.scanDir(dir, core)) == 0 {
+			if fallback := l.fallbackCore(scanCore, p); fallback != "" {
+				core = fallback
+				p.core = core
+				dir = l.assetDir(p)
+			}
+		}
+
+		owned := dir
+		if l.assetName != "" {
+			owned = filepath.Join(dir, l.assetName)
+		}
+		if rel, err := filepath.Rel(subDirPath, owned); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			claims = append(claims, strings.Split(filepath.ToSlash(rel), "/")[0])
+		}
+
+		items = append(items, l.scanDir(dir, core)...)
+	}
+	return items, claims
+}
+
+func (l *saveLayout) scanDir(dir, core string) []types.FileItem {
+	if l.assetName != "" {
+		assetPath := filepath.Join(dir, l.assetName)
+		info, err := os.Stat(assetPath)
+		if err != nil || info.IsDir() != l.dirAssets {
+			return nil
+		}
+		return []types.FileItem{{Name: l.assetName, Core: core, UpdatedAt: assetModTime(assetPath, info)}}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	items := make([]types.FileItem, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() != l.dirAssets || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if l.pattern != "" {
+			if ok, _ := filepath.Match(l.pattern, name); !ok {
+				continue
+			}
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		items = append(items, types.FileItem{Name: name, Core: core, UpdatedAt: assetModTime(filepath.Join(dir, name), info)})
+	}
+	return items
+}
+
+func assetModTime(path string, info os.FileInfo) string {
+	if info.IsDir() {
+		return getDirLatestModTime(path).UTC().Format(time.RFC3339)
+	}
+	return info.ModTime().UTC().Format(time.RFC3339)
+}
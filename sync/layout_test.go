@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLayouts(t *testing.T) {
+	romDir := filepath.Join("lib", "snes", "1")
+	biosDir := filepath.Join("lib", "bios")
+
+	tests := []struct {
+		layout    string
+		platform  string
+		subDir    string
+		core      string // RomM emulator value or local core
+		filename  string
+		wantPath  string
+		dirAssets bool
+	}{
+		{"default", "snes", "saves", "snes9x", "game.srm", filepath.Join(romDir, "saves", "snes9x", "game.srm"), false},
+		{"default", "snes", "states", "snes9x", "game.state", filepath.Join(romDir, "states", "snes9x", "game.state"), false},
+		{layoutPS2Saves, "ps2", "saves", corePCSX2, "BASLUS-20312GAME.psu", filepath.Join(romDir, "saves", corePCSX2, "BASLUS-20312GAME.psu"), false},
+		{"pcsx2-memcards", "ps2", "saves", corePCSX2, "Mcd001.ps2", filepath.Join(biosDir, "pcsx2", "memcards", "Mcd001.ps2"), false},
+		{"default", "ps2", "states", corePCSX2, "game.state", filepath.Join(romDir, "states", corePCSX2, "game.state"), false},
+		{"ppsspp-savedata", "psp", "saves", "PPSSPP", "ULUS10374SO10000", filepath.Join(romDir, "saves", "PPSSPP", "PSP", "SAVEDATA", "ULUS10374SO10000"), true},
+		{"ppsspp-savedata", "psp", "saves", corePPSSPP_LR, "ULUS10374SO10000", filepath.Join(romDir, "saves", corePPSSPP_LR, "PSP", "SAVEDATA", "ULUS10374SO10000"), true},
+		{"azahar", "3ds", "saves", "azahar_libretro", "Azahar", filepath.Join(romDir, "saves", "Azahar"), true},
+		{"dolphin-wii", "wii", "saves", coreDolphin, "Wii", filepath.Join(romDir, "saves", coreDolphin, "User", "Wii"), true},
+		{"dolphin-gc", "gamecube", "saves", "Card A", "save.gci", filepath.Join(romDir, "saves", coreDolphin, "User", "GC", "USA", "Card A", "save.gci"), false},
+		{"dolphin-gc", "gamecube", "saves", `dolphin-emu\User\GC\EUR\Card B`, "save.gci", filepath.Join(romDir, "saves", coreDolphin, "User", "GC", "EUR", "Card B", "save.gci"), false},
+		{"flycast-vmu", "dc", "saves", coreFlycast, "vmu_save_A1.bin", filepath.Join(biosDir, "dc", "vmu_save_A1.bin"), false},
+		{"default", "dc", "saves", "Flycast", "game.A1.bin", filepath.Join(romDir, "saves", "Flycast", "game.A1.bin"), false},
+		{"mupen64plus", "n64", "saves", "mupen64plus_next_libretro", "game.srm", filepath.Join(romDir, "saves", coreMupenNext, "game.srm"), false},
+		{"mupen64plus", "n64", "states", coreMupenNext, "game.state", filepath.Join(romDir, "states", coreMupenNext, "game.state"), false},
+		{"snes9x", "snes", "saves", "snes9x_libretro", "game.srm", filepath.Join(romDir, "saves", "Snes9x", "game.srm"), false},
+		{"desmume", "nds", "saves", "DeSmuME", "game.dsv", filepath.Join(romDir, "saves", "DeSmuME", "game.dsv"), false},
+	}
+
+	for _, tt := range tests {
+		l := layoutFor(tt.platform, tt.subDir, tt.core, tt.filename)
+		if l.name != tt.layout {
+			t.Errorf("layoutFor(%s, %s, %s, %s) = %s, want %s", tt.platform, tt.subDir, tt.core, tt.filename, l.name, tt.layout)
+			continue
+		}
+		if l.dirAssets != tt.dirAssets {
+			t.Errorf("%s: dirAssets = %v, want %v", tt.layout, l.dirAssets, tt.dirAssets)
+		}
+		_, got := getLocalAssetPaths(romDir, biosDir, tt.subDir, tt.core, tt.filename, tt.platform)
+		if got != tt.wantPath {
+			t.Errorf("%s: path = %s, want %s", tt.layout, got, tt.wantPath)
+		}
+	}
+}
+
+func TestGetSaves_FlycastVMU(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 7, PlatformSlug: "dc", FullPath: "dc/game.chd"}
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	vmuDir := filepath.Join(tempDir, "bios", "dc")
+	if err := os.MkdirAll(vmuDir, 0o755); err != nil {
+		t.Fatalf("failed to create vmu dir: %v", err)
+	}
+	for _, name := range []string{"vmu_save_A1.bin", "dc_flash.bin"} {
+		if err := os.WriteFile(filepath.Join(vmuDir, name), []byte("data"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	saves, err := s.listLocalFiles(&game, "saves")
+	if err != nil {
+		t.Fatalf("listLocalFiles failed: %v", err)
+	}
+	if len(saves) != 1 || saves[0].Name != "vmu_save_A1.bin" || saves[0].Core != coreFlycast {
+		t.Errorf("Expected only the VMU save, got %+v", saves)
+	}
+}
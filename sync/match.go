@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"go-romm-sync/romm"
+	"go-romm-sync/types"
+	"go-romm-sync/utils"
+	"regexp"
+	"time"
+)
+
+// syncTolerance absorbs clock drift and transfer latency when comparing
+// local modification times with server timestamps.
+const syncTolerance = 5 * time.Second
+
+// serverTimestampRe matches the " [2023-01-01_12-00-00]" suffix RomM appends
+// to uploaded save and state file names.
+var serverTimestampRe = regexp.MustCompile(` \[\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}(?:-\d+)?\]`)
+
+// cleanServerFileName strips the RomM upload timestamp and the device tag from a
+// server file name so it can be matched against the local file name.
+func cleanServerFileName(name string) string {
+	return romm.StripDeviceTag(serverTimestampRe.ReplaceAllString(name, ""))
+}
+
+// assetKey returns the key used to match a local asset with its server copies.
+// Cores are normalized through the save layouts so that Windows-style emulator
+// paths and RomM emulator names resolve to the same local folder.
+func assetKey(core, name string) string {
+	core = canonicalCore(core)
+	return core + "/" + name
+}
+
+// latestServerAssets groups server assets by asset key and returns the most
+// recently updated copy of each.
+func latestServerAssets(assets []types.ServerAsset) map[string]types.ServerAsset {
+	latest := make(map[string]types.ServerAsset, len(assets))
+	for _, a := range assets {
+		key := assetKey(a.Emulator, cleanServerFileName(a.FileName))
+		if cur, ok := latest[key]; !ok || parseTime(a.UpdatedAt).After(parseTime(cur.UpdatedAt)) {
+			latest[key] = a
+		}
+	}
+	return latest
+}
+
+func serverSaveAssets(saves []types.ServerSave) []types.ServerAsset {
+	assets := make([]types.ServerAsset, len(saves))
+	for i := range saves {
+		assets[i] = saves[i].ServerAsset
+	}
+	return assets
+}
+
+func serverStateAssets(states []types.ServerState) []types.ServerAsset {
+	assets := make([]types.ServerAsset, len(states))
+	for i := range states {
+		assets[i] = states[i].ServerAsset
+	}
+	return assets
+}
+
+// parseTime parses a timestamp, returning the zero time when it is empty or invalid.
+func parseTime(s string) time.Time {
+	t, err := utils.ParseTimestamp(s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// sameInstant reports whether two non-zero timestamps are within syncTolerance.
+func sameInstant(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return false
+	}
+	d := a.Sub(b)
+	return d < syncTolerance && d > -syncTolerance
+}
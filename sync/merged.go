@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"sort"
+)
+
+// MergedAsset is one save or state of a game as seen from both sides: the local
+// file, every server copy of it and its sync status.
+type MergedAsset struct {
+	AssetSyncStatus
+	LocalPath    string              `json:"local_path,omitempty"`
+	LocalSize    int64               `json:"local_size"`
+	ServerCopies []types.ServerAsset `json:"server_copies,omitempty"` // newest first
+}
+
+// GetMergedAssets returns the local and server saves and states of a game merged
+// into one list ordered by type, core and file name. Server copies are matched to
+// local files through the save layouts, so renamed cores and emulator folders line
+// up with the local directory they are stored in.
+func (s *Service) GetMergedAssets(id uint) ([]MergedAsset, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	saves, err := s.romm.GetServerSaves(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server saves: %w", err)
+	}
+	states, err := s.romm.GetServerStates(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server states: %w", err)
+	}
+
+	merged, err := s.mergeAssets(&game, constants.DirSaves, serverSaveAssets(saves))
+	if err != nil {
+		return nil, err
+	}
+	mergedStates, err := s.mergeAssets(&game, constants.DirStates, serverStateAssets(states))
+	if err != nil {
+		return nil, err
+	}
+	return append(merged, mergedStates...), nil
+}
+
+func (s *Service) mergeAssets(game *types.Game, subDir string, server []types.ServerAsset) ([]MergedAsset, error) {
+	statuses, err := s.assetStatuses(game, subDir, server)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make(map[string][]types.ServerAsset)
+	for _, a := range server {
+		key := assetKey(a.Emulator, cleanServerFileName(a.FileName))
+		copies[key] = append(copies[key], a)
+	}
+	for _, c := range copies {
+		sort.SliceStable(c, func(i, j int) bool {
+			return parseTime(c[i].UpdatedAt).After(parseTime(c[j].UpdatedAt))
+		})
+	}
+
+	romDir, biosDir := s.library.GetRomDir(game), s.library.GetBiosDir()
+	platform := getPlatformSlug(game)
+
+	merged := make([]MergedAsset, 0, len(statuses))
+	for _, st := range statuses {
+		m := MergedAsset{AssetSyncStatus: st, ServerCopies: copies[assetKey(st.Core, st.Name)]}
+		if st.Local != nil {
+			_, m.LocalPath = getLocalAssetPaths(romDir, biosDir, subDir, st.Local.Core, st.Local.Name, platform)
+			if stat, ok := statAsset(m.LocalPath); ok {
+				m.LocalSize = stat.size
+			}
+		}
+		merged = append(merged, m)
+	}
+	return merged, nil
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetMergedAssets(t *testing.T) {
+	tempDir := t.TempDir()
+
+	game := types.Game{ID: 1, PlatformSlug: "gamecube", FullPath: "gamecube/game.iso"}
+	gameData, _ := json.Marshal(game)
+
+	cardDir := filepath.Join(tempDir, "gamecube", "1", "saves", "dolphin-emu", "User", "GC", "USA", "Card A")
+	if err := os.MkdirAll(cardDir, 0o755); err != nil {
+		t.Fatalf("failed to create card dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cardDir, "save.gci"), []byte("card"), 0o644); err != nil {
+		t.Fatalf("failed to write card file: %v", err)
+	}
+
+	// Server copies uploaded from Windows use backslashes in the emulator path.
+	savesData, _ := json.Marshal([]types.ServerSave{
+		{ServerAsset: types.ServerAsset{ID: 1, FileName: "save [2024-01-01_10-00-00].gci", Emulator: `dolphin-emu\User\GC\USA\Card A`, UpdatedAt: "2024-01-01T10:00:00Z", FileSize: 3}},
+		{ServerAsset: types.ServerAsset{ID: 2, FileName: "save {@deck} [2024-01-02_10-00-00].gci", Emulator: `dolphin-emu\User\GC\USA\Card A`, UpdatedAt: "2024-01-02T10:00:00Z", FileSize: 5}},
+		{ServerAsset: types.ServerAsset{ID: 3, FileName: "other.gci", Emulator: "dolphin-emu/User/GC/USA/Card A", UpdatedAt: "2024-01-01T10:00:00Z"}},
+	})
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			switch req.URL.Path {
+			case "/api/saves":
+				body = savesData
+			case "/api/states":
+				body = []byte("[]")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	merged, err := s.GetMergedAssets(1)
+	if err != nil {
+		t.Fatalf("GetMergedAssets failed: %v", err)
+	}
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 merged assets, got %+v", merged)
+	}
+
+	other := merged[0]
+	if other.Name != "other.gci" || other.Local != nil || other.Status != StatusServerAhead || len(other.ServerCopies) != 1 {
+		t.Errorf("Unexpected server-only asset: %+v", other)
+	}
+
+	save := merged[1]
+	if save.Type != constants.DirSaves || save.Name != "save.gci" || save.Core != "dolphin-emu/User/GC/USA/Card A" {
+		t.Errorf("Unexpected merged save: %+v", save)
+	}
+	if save.Local == nil || save.LocalSize != 4 || save.LocalPath != filepath.Join(cardDir, "save.gci") {
+		t.Errorf("Expected the local card file, got %+v", save)
+	}
+	if len(save.ServerCopies) != 2 || save.ServerCopies[0].ID != 2 || save.ServerCopies[0].Device != "deck" {
+		t.Errorf("Expected both server copies newest first, got %+v", save.ServerCopies)
+	}
+	if save.Server == nil || save.Server.ID != 2 || save.Status != StatusLocalAhead {
+		t.Errorf("Expected the newest server copy and local_ahead, got %+v", save)
+	}
+}
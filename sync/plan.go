@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+)
+
+// PlanAction is what a sync does with one save or state.
+type PlanAction string
+
+const (
+	// ActionUpload uploads the local copy to RomM.
+	ActionUpload PlanAction = "upload"
+	// ActionDownload replaces the local copy with the newest server copy.
+	ActionDownload PlanAction = "download"
+	// ActionSkip leaves both copies alone because they are in sync.
+	ActionSkip PlanAction = "skip"
+	// ActionConflict leaves both copies alone because both changed.
+	ActionConflict PlanAction = "conflict"
+)
+
+// planAction returns the action a sync takes for an asset with the given status.
+func planAction(status SyncStatus) PlanAction {
+	switch status {
+	case StatusLocalAhead:
+		return ActionUpload
+	case StatusServerAhead:
+		return ActionDownload
+	case StatusConflict:
+		return ActionConflict
+	}
+	return ActionSkip
+}
+
+// PlannedAction is one step of a sync plan.
+type PlannedAction struct {
+	GameID          uint       `json:"game_id"`
+	Type            string     `json:"type"` // constants.DirSaves or constants.DirStates
+	Core            string     `json:"core"`
+	Name            string     `json:"name"`
+	Action          PlanAction `json:"action"`
+	Status          SyncStatus `json:"status"`
+	LocalPath       string     `json:"local_path,omitempty"` // file read by an upload or written by a download
+	ServerID        uint       `json:"server_id,omitempty"`
+	LocalSize       int64      `json:"local_size"`
+	ServerSize      int64      `json:"server_size"`
+	LocalUpdatedAt  string     `json:"local_updated_at,omitempty"`
+	ServerUpdatedAt string     `json:"server_updated_at,omitempty"`
+}
+
+// PlanFailure reports a game that could not be compared with RomM.
+type PlanFailure struct {
+	GameID uint   `json:"game_id"`
+	Title  string `json:"title"`
+	Error  string `json:"error"`
+}
+
+// SyncPlan lists the actions a sync would take, in the order it takes them.
+type SyncPlan struct {
+	Actions   []PlannedAction `json:"actions"`
+	Uploads   int             `json:"uploads"`
+	Downloads int             `json:"downloads"`
+	Skips     int             `json:"skips"`
+	Conflicts int             `json:"conflicts"`
+	Failures  []PlanFailure   `json:"failures,omitempty"`
+}
+
+func (p *SyncPlan) add(actions []PlannedAction) {
+	for _, a := range actions {
+		switch a.Action {
+		case ActionUpload:
+			p.Uploads++
+		case ActionDownload:
+			p.Downloads++
+		case ActionConflict:
+			p.Conflicts++
+		default:
+			p.Skips++
+		}
+	}
+	p.Actions = append(p.Actions, actions...)
+}
+
+// PlanGameSync returns the actions a sync of one game would take without touching
+// any file. PS2 saves are planned as last exported from the memory card.
+func (s *Service) PlanGameSync(id uint) (SyncPlan, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return SyncPlan{}, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	actions, err := s.planGame(&game)
+	if err != nil {
+		return SyncPlan{}, err
+	}
+	var plan SyncPlan
+	plan.add(actions)
+	return plan, nil
+}
+
+// PlanLibrarySync returns the actions SyncLibrary would take, game by game. Games
+// that cannot be compared are listed as failures.
+func (s *Service) PlanLibrarySync() (SyncPlan, error) {
+	games, err := s.downloadedGames()
+	if err != nil {
+		return SyncPlan{}, err
+	}
+	var plan SyncPlan
+	for i := range games {
+		actions, err := s.planGame(&games[i])
+		if err != nil {
+			s.ui.LogErrorf("PlanLibrarySync: Failed to plan %d: %v", games[i].ID, err)
+			plan.Failures = append(plan.Failures, PlanFailure{GameID: games[i].ID, Title: games[i].Title, Error: err.Error()})
+			continue
+		}
+		plan.add(actions)
+	}
+	return plan, nil
+}
+
+func (s *Service) planGame(game *types.Game) ([]PlannedAction, error) {
+	saves, err := s.romm.GetServerSaves(game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server saves: %w", err)
+	}
+	states, err := s.romm.GetServerStates(game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server states: %w", err)
+	}
+
+	romDir, biosDir := s.library.GetRomDir(game), s.library.GetBiosDir()
+	platform := getPlatformSlug(game)
+
+	var actions []PlannedAction
+	for _, set := range []struct {
+		subDir string
+		server []types.ServerAsset
+	}{
+		{constants.DirSaves, serverSaveAssets(saves)},
+		{constants.DirStates, serverStateAssets(states)},
+	} {
+		local, err := s.listLocalFiles(game, set.subDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list local %s: %w", set.subDir, err)
+		}
+		statuses, err := s.classifyAssets(game, set.subDir, local, set.server)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range statuses {
+			a := PlannedAction{
+				GameID: game.ID,
+				Type:   st.Type,
+				Core:   st.Core,
+				Name:   st.Name,
+				Action: planAction(st.Status),
+				Status: st.Status,
+			}
+			if st.Local != nil {
+				_, a.LocalPath = getLocalAssetPaths(romDir, biosDir, st.Type, st.Local.Core, st.Local.Name, platform)
+				if stat, ok := statAsset(a.LocalPath); ok {
+					a.LocalSize = stat.size
+				}
+				a.LocalUpdatedAt = st.Local.UpdatedAt
+			}
+			if st.Server != nil {
+				a.ServerID = st.Server.ID
+				a.ServerSize = st.Server.FileSize
+				a.ServerUpdatedAt = st.Server.UpdatedAt
+			}
+			if a.Action == ActionDownload {
+				a.LocalPath, _ = s.assetDestPath(game, st.Server.Emulator, st.Name, st.Type)
+			}
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanLibrarySync(t *testing.T) {
+	tempDir := t.TempDir()
+
+	romDir := writeLibraryGame(t, tempDir, &types.Game{ID: 1, Title: "One", FullPath: "snes/one.sfc"}, true)
+	writeLibraryGame(t, tempDir, &types.Game{ID: 2, Title: "Two", FullPath: "snes/two.sfc"}, true)
+
+	savesDir := filepath.Join(romDir, "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "one.srm")
+	if err := os.WriteFile(savePath, []byte("progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	statesData, _ := json.Marshal([]types.ServerState{
+		{ServerAsset: types.ServerAsset{ID: 20, FileName: "one.state", Emulator: "snes9x", UpdatedAt: "2024-03-01T10:00:00Z", FileSize: 42}},
+	})
+
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				t.Errorf("Unexpected %s %s while planning", req.Method, req.URL.Path)
+			}
+			body := []byte("[]")
+			switch {
+			case req.URL.Path == "/api/states" && req.URL.Query().Get("rom_id") == "1":
+				body = statesData
+			case req.URL.Query().Get("rom_id") == "2":
+				return &http.Response{StatusCode: http.StatusInternalServerError, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	plan, err := s.PlanLibrarySync()
+	if err != nil {
+		t.Fatalf("PlanLibrarySync failed: %v", err)
+	}
+
+	if len(plan.Actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %+v", plan.Actions)
+	}
+	upload := plan.Actions[0]
+	if upload.Action != ActionUpload || upload.Type != constants.DirSaves || upload.Name != "one.srm" ||
+		upload.LocalPath != savePath || upload.LocalSize != int64(len("progress")) {
+		t.Errorf("Unexpected upload action: %+v", upload)
+	}
+	statePath := filepath.Join(romDir, "states", "snes9x", "one.state")
+	download := plan.Actions[1]
+	if download.Action != ActionDownload || download.ServerID != 20 || download.ServerSize != 42 ||
+		download.LocalPath != statePath {
+		t.Errorf("Unexpected download action: %+v", download)
+	}
+	if plan.Uploads != 1 || plan.Downloads != 1 || plan.Skips != 0 || plan.Conflicts != 0 {
+		t.Errorf("Unexpected counts: %+v", plan)
+	}
+	if len(plan.Failures) != 1 || plan.Failures[0].GameID != 2 {
+		t.Errorf("Expected game 2 to fail, got %+v", plan.Failures)
+	}
+
+	if _, err := os.Stat(filepath.Dir(statePath)); !os.IsNotExist(err) {
+		t.Errorf("Planning should not create %s", filepath.Dir(statePath))
+	}
+	if _, err := os.Stat(filepath.Join(romDir, journalFileName)); !os.IsNotExist(err) {
+		t.Errorf("Planning should not write the journal")
+	}
+}
@@ -0,0 +1,241 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go-romm-sync/utils/ps2mc"
+)
+
+// PCSX2 keeps every game's saves on a shared memory card in the BIOS directory.
+// Once a game's serial is known, its save directories are exported from the card
+// as .psu files under <romDir>/saves/pcsx2_libretro, which are synced instead of
+// the whole card, and downloaded .psu files are injected back into the card.
+const (
+	layoutPS2Saves = "pcsx2-psu"
+	ps2CardName    = "Mcd001.ps2"
+	isoSectorSize  = 2048
+)
+
+func ps2CardPath(biosDir string) string {
+	return filepath.Join(biosDir, "pcsx2", "memcards", ps2CardName)
+}
+
+var (
+	// boot2Re matches the executable line of SYSTEM.CNF, e.g. "BOOT2 = cdrom0:\SLUS_203.12;1".
+	boot2Re = regexp.MustCompile(`BOOT2\s*=\s*cdrom0:\\?([A-Z]{4})_(\d{3})\.(\d{2})`)
+	// serialRe matches a serial in a file name, e.g. "[SLUS-20312]" or "SLUS_203.12".
+	serialRe = regexp.MustCompile(`\b([A-Z]{4})[-_ ]?(\d{3})\.?(\d{2})\b`)
+)
+
+// readPS2Serial returns the serial, e.g. "SLUS-20312", of a PS2 ISO image from the
+// BOOT2 line of its SYSTEM.CNF.
+func readPS2Serial(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	pvd := make([]byte, isoSectorSize)
+	if _, err := f.ReadAt(pvd, 16*isoSectorSize); err != nil {
+		return "", fmt.Errorf("failed to read volume descriptor: %w", err)
+	}
+	if pvd[0] != 1 || string(pvd[1:6]) != "CD001" {
+		return "", fmt.Errorf("%s is not an ISO 9660 image", filepath.Base(path))
+	}
+
+	// The root directory record is embedded in the primary volume descriptor.
+	rootLBA := binary.LittleEndian.Uint32(pvd[156+2:])
+	rootLen := binary.LittleEndian.Uint32(pvd[156+10:])
+	if rootLen > 64*isoSectorSize {
+		rootLen = 64 * isoSectorSize
+	}
+	root := make([]byte, rootLen)
+	if _, err := f.ReadAt(root, int64(rootLBA)*isoSectorSize); err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read root directory: %w", err)
+	}
+
+	for off := 0; off < len(root); {
+		recLen := int(root[off])
+		if recLen == 0 {
+			// Records never cross sector boundaries; skip the padding.
+			off = (off/isoSectorSize + 1) * isoSectorSize
+			continue
+		}
+		if off+recLen > len(root) || recLen < 34 {
+			break
+		}
+		rec := root[off : off+recLen]
+		if 33+int(rec[32]) > len(rec) {
+			return "", fmt.Errorf("malformed directory record in %s", filepath.Base(path))
+		}
+		name := string(rec[33 : 33+int(rec[32])])
+		if strings.EqualFold(strings.TrimSuffix(name, ";1"), "SYSTEM.CNF") {
+			size := binary.LittleEndian.Uint32(rec[10:])
+			if size > isoSectorSize {
+				size = isoSectorSize
+			}
+			cnf := make([]byte, size)
+			if _, err := f.ReadAt(cnf, int64(binary.LittleEndian.Uint32(rec[2:]))*isoSectorSize); err != nil && err != io.EOF {
+				return "", fmt.Errorf("failed to read SYSTEM.CNF: %w", err)
+			}
+			m := boot2Re.FindSubmatch(cnf)
+			if m == nil {
+				return "", fmt.Errorf("no BOOT2 entry in SYSTEM.CNF")
+			}
+			return fmt.Sprintf("%s-%s%s", m[1], m[2], m[3]), nil
+		}
+		off += recLen
+	}
+	return "", fmt.Errorf("no SYSTEM.CNF in %s", filepath.Base(path))
+}
+
+// serialFromFileName reads a serial from a file name, as in "Game [SLUS-20312].iso".
+func serialFromFileName(name string) string {
+	m := serialRe.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s%s", m[1], m[2], m[3])
+}
+
+// ps2Serial returns the serial of a PS2 game, read from the disc image or else
+// from its file name. It returns "" for other platforms and unknown serials.
+func (s *Service) ps2Serial(game *types.Game) string {
+	if getPlatformSlug(game) != "ps2" {
+		return ""
+	}
+	if romPath := s.discImage(game); romPath != "" {
+		serial, err := s.discs.read("ps2-serial", romPath, readPS2Serial)
+		if err == nil {
+			return serial
+		}
+		s.ui.LogInfof("ps2Serial: Could not read serial of %s: %v", romPath, err)
+	}
+	if serial := serialFromFileName(game.FSName); serial != "" {
+		return serial
+	}
+	return serialFromFileName(filepath.Base(game.FullPath))
+}
+
+// exportPS2Saves writes the game's save directories on the shared memory card to
+// .psu files, setting their modification time to that of the save.
+func (s *Service) exportPS2Saves(p layoutPaths) {
+	if p.serial == "" || p.subDir != constants.DirSaves {
+		return
+	}
+	cardPath := ps2CardPath(p.biosDir)
+	s.cardMu.Lock()
+	card, err := ps2mc.Open(cardPath)
+	s.cardMu.Unlock()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.ui.LogErrorf("exportPS2Saves: Failed to read memory card %s: %v", cardPath, err)
+		}
+		return
+	}
+	saves, err := card.Saves()
+	if err != nil {
+		s.ui.LogErrorf("exportPS2Saves: Failed to list memory card %s: %v", cardPath, err)
+		return
+	}
+
+	dir := filepath.Join(p.romDir, p.subDir, corePCSX2)
+	for _, save := range saves {
+		if !strings.Contains(save.Name, p.serial) || filepath.Base(save.Name) != save.Name {
+			continue
+		}
+		data, err := card.ExportPSU(save.Name)
+		if err != nil {
+			s.ui.LogErrorf("exportPS2Saves: Failed to export %s: %v", save.Name, err)
+			continue
+		}
+		dest := filepath.Join(dir, save.Name+".psu")
+		if existing, err := os.ReadFile(dest); err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			s.ui.LogErrorf("exportPS2Saves: Failed to create %s: %v", dir, err)
+			return
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			s.ui.LogErrorf("exportPS2Saves: Failed to write %s: %v", dest, err)
+			continue
+		}
+		if !save.Modified.IsZero() {
+			_ = os.Chtimes(dest, save.Modified, save.Modified)
+		}
+	}
+}
+
+// importPS2Save injects a downloaded .psu file into the shared memory card,
+// replacing the save directory of the same name. The card is backed up first, and
+// a new card is formatted when there is none.
+func (s *Service) importPS2Save(game *types.Game, subDir, core, filename, path string) error {
+	if layoutFor(getPlatformSlug(game), subDir, core, filename).name != layoutPS2Saves {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Games syncing in parallel share the card; each import must see the last.
+	s.cardMu.Lock()
+	defer s.cardMu.Unlock()
+
+	cardPath := ps2CardPath(s.library.GetBiosDir())
+	card, err := ps2mc.Open(cardPath)
+	if os.IsNotExist(err) {
+		card = ps2mc.Format()
+	} else if err != nil {
+		return fmt.Errorf("failed to read memory card: %w", err)
+	}
+	name, err := card.ImportPSU(data)
+	if err != nil {
+		return fmt.Errorf("failed to import %s into memory card: %w", filename, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cardPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create memory card directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cardPath), ps2CardName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create memory card file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(card.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write memory card: %w", err)
+	}
+	if err := s.backupAsset(game, constants.DirSaves, corePCSX2, ps2CardName, cardPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, cardPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace memory card: %w", err)
+	}
+
+	// Store the save as the next export will produce it, so it is not seen as changed.
+	if exported, err := card.ExportPSU(name); err == nil {
+		if err := os.WriteFile(path, exported, 0o644); err != nil {
+			s.ui.LogErrorf("importPS2Save: Failed to rewrite %s: %v", path, err)
+		}
+	}
+	s.ui.LogInfof("importPS2Save: Imported %s into %s", name, cardPath)
+	return nil
+}
@@ -0,0 +1,261 @@
+package sync
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"go-romm-sync/types"
+	"go-romm-sync/utils/ps2mc"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+)
+
+// makePSU builds a .psu file holding a save directory with a single file.
+func makePSU(dir string, data []byte) []byte {
+	entry := func(mode uint16, length int, name string) []byte {
+		b := make([]byte, 512)
+		binary.LittleEndian.PutUint16(b, mode)
+		binary.LittleEndian.PutUint32(b[4:], uint32(length))
+		binary.LittleEndian.PutUint16(b[0x1E:], 2024) // modification year
+		b[0x1D], b[0x1C] = 1, 1
+		copy(b[0x40:], name)
+		return b
+	}
+	out := entry(0x8427, 3, dir)
+	out = append(out, entry(0x8427, 0, ".")...)
+	out = append(out, entry(0xA426, 0, "..")...)
+	out = append(out, entry(0x8497, len(data), "data")...)
+	out = append(out, data...)
+	return append(out, make([]byte, (1024-len(data)%1024)%1024)...)
+}
+
+// writePS2Card writes a memory card holding the given saves to the BIOS directory.
+func writePS2Card(t *testing.T, biosDir string, saves map[string][]byte) *ps2mc.Card {
+	t.Helper()
+	card := ps2mc.Format()
+	for name, data := range saves {
+		if _, err := card.ImportPSU(makePSU(name, data)); err != nil {
+			t.Fatalf("failed to import %s: %v", name, err)
+		}
+	}
+	cardPath := ps2CardPath(biosDir)
+	if err := os.MkdirAll(filepath.Dir(cardPath), 0o755); err != nil {
+		t.Fatalf("failed to create memcards dir: %v", err)
+	}
+	if err := os.WriteFile(cardPath, card.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write card: %v", err)
+	}
+	return card
+}
+
+// makePS2ISO builds a disc image whose SYSTEM.CNF boots the given executable.
+func makePS2ISO(bootFile string) []byte {
+	iso := make([]byte, 21*isoSectorSize)
+	pvd := iso[16*isoSectorSize:]
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	root := pvd[156:]
+	binary.LittleEndian.PutUint32(root[2:], 18)
+	binary.LittleEndian.PutUint32(root[10:], isoSectorSize)
+
+	cnf := []byte("BOOT2 = cdrom0:\\" + bootFile + ";1\r\nVER = 1.00\r\n")
+	copy(iso[20*isoSectorSize:], cnf)
+	rec := iso[18*isoSectorSize:]
+	name := "SYSTEM.CNF;1"
+	rec[0] = byte(33 + len(name) + 1)
+	binary.LittleEndian.PutUint32(rec[2:], 20)
+	binary.LittleEndian.PutUint32(rec[10:], uint32(len(cnf)))
+	rec[32] = byte(len(name))
+	copy(rec[33:], name)
+	return iso
+}
+
+func TestReadPS2Serial(t *testing.T) {
+	iso := makePS2ISO("SLUS_203.12")
+	rec := iso[18*isoSectorSize:]
+
+	path := filepath.Join(t.TempDir(), "game.iso")
+	if err := os.WriteFile(path, iso, 0o644); err != nil {
+		t.Fatalf("failed to write iso: %v", err)
+	}
+	if serial, err := readPS2Serial(path); err != nil || serial != "SLUS-20312" {
+		t.Errorf("Expected SLUS-20312, got %q (err %v)", serial, err)
+	}
+
+	// A directory record whose name runs past its end is rejected, not sliced.
+	rec[32] = 200
+	if err := os.WriteFile(path, iso, 0o644); err != nil {
+		t.Fatalf("failed to write iso: %v", err)
+	}
+	if _, err := readPS2Serial(path); err == nil {
+		t.Error("Expected an error for a malformed directory record")
+	}
+
+	if got := serialFromFileName("Paladin Quest (USA) [SLES-50312].chd"); got != "SLES-50312" {
+		t.Errorf("Expected SLES-50312 from file name, got %q", got)
+	}
+	if got := serialFromFileName("Paladin Quest (USA).iso"); got != "" {
+		t.Errorf("Expected no serial, got %q", got)
+	}
+}
+
+func TestPS2Serial_MultiDisc(t *testing.T) {
+	tempDir := t.TempDir()
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	game := types.Game{ID: 5, PlatformSlug: "ps2", FullPath: "ps2/Paladin Quest", HasMultipleFiles: true}
+	romDir := lib.GetRomDir(&game)
+	if err := os.MkdirAll(romDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"Paladin Quest.m3u":          []byte("Paladin Quest (Disc 1).iso\nPaladin Quest (Disc 2).iso\n"),
+		"Paladin Quest (Disc 1).iso": makePS2ISO("SLUS_203.12"),
+		"Paladin Quest (Disc 2).iso": makePS2ISO("SLUS_203.13"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(romDir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The serial is read from the first disc, not from the playlist.
+	if got := s.ps2Serial(&game); got != "SLUS-20312" {
+		t.Errorf("Expected SLUS-20312 from the first disc, got %q", got)
+	}
+}
+
+func TestExportGameSaves_PS2(t *testing.T) {
+	tempDir := t.TempDir()
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+	writePS2Card(t, lib.GetBiosDir(), map[string][]byte{
+		"BASLUS-20312GAME": []byte("ours"),
+		"BESLES-50000SAVE": []byte("other game"),
+	})
+
+	game := types.Game{ID: 3, PlatformSlug: "ps2", FullPath: "ps2/Paladin Quest [SLUS-20312].iso"}
+	// Listing is read-only: nothing is exported until a sync or upload asks for it.
+	saves, err := s.listLocalFiles(&game, "saves")
+	if err != nil {
+		t.Fatalf("listLocalFiles failed: %v", err)
+	}
+	if len(saves) != 0 {
+		t.Fatalf("Expected no saves before exporting, got %+v", saves)
+	}
+
+	s.exportGameSaves(&game)
+	saves, err = s.listLocalFiles(&game, "saves")
+	if err != nil {
+		t.Fatalf("listLocalFiles failed: %v", err)
+	}
+	if len(saves) != 1 || saves[0].Name != "BASLUS-20312GAME.psu" || saves[0].Core != corePCSX2 {
+		t.Fatalf("Expected only the game's exported save, got %+v", saves)
+	}
+
+	psuPath := filepath.Join(lib.GetRomDir(&game), "saves", corePCSX2, "BASLUS-20312GAME.psu")
+	data, err := os.ReadFile(psuPath)
+	if err != nil {
+		t.Fatalf("Expected exported psu: %v", err)
+	}
+	if info, err := ps2mc.PSUInfo(data); err != nil || info.Name != "BASLUS-20312GAME" || info.Size != 4 {
+		t.Errorf("Unexpected exported psu: %+v (err %v)", info, err)
+	}
+
+	// Without a serial, the whole card is synced as before.
+	unknown := types.Game{ID: 4, PlatformSlug: "ps2", FullPath: "ps2/Unknown.iso"}
+	s.exportGameSaves(&unknown)
+	saves, err = s.listLocalFiles(&unknown, "saves")
+	if err != nil {
+		t.Fatalf("listLocalFiles failed: %v", err)
+	}
+	if len(saves) != 1 || saves[0].Name != ps2CardName {
+		t.Errorf("Expected the whole memory card, got %+v", saves)
+	}
+}
+
+func TestDownloadServerSave_PS2ImportsIntoCard(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 3, PlatformSlug: "ps2", FullPath: "ps2/Paladin Quest [SLUS-20312].iso"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, makePSU("BASLUS-20312GAME", []byte("from server")))
+	s := New(cm, lib, romm, &MockUIProvider{})
+	writePS2Card(t, lib.GetBiosDir(), map[string][]byte{
+		"BASLUS-20312GAME": []byte("old"),
+		"BESLES-50000SAVE": []byte("other game"),
+	})
+
+	if err := s.DownloadServerSave(3, 9, corePCSX2, "BASLUS-20312GAME.psu", ""); err != nil {
+		t.Fatalf("DownloadServerSave failed: %v", err)
+	}
+
+	card, err := ps2mc.Open(ps2CardPath(lib.GetBiosDir()))
+	if err != nil {
+		t.Fatalf("failed to open card: %v", err)
+	}
+	saves, err := card.Saves()
+	if err != nil {
+		t.Fatalf("Saves failed: %v", err)
+	}
+	sizes := make(map[string]int64)
+	for _, save := range saves {
+		sizes[save.Name] = save.Size
+	}
+	if len(sizes) != 2 || sizes["BASLUS-20312GAME"] != int64(len("from server")) || sizes["BESLES-50000SAVE"] != int64(len("other game")) {
+		t.Errorf("Expected the game's save replaced and others kept, got %+v", saves)
+	}
+
+	backups, err := s.ListBackups(3)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Name != ps2CardName {
+		t.Errorf("Expected a backup of the memory card, got %+v", backups)
+	}
+}
+
+func TestImportPS2Save_Concurrent(t *testing.T) {
+	tempDir := t.TempDir()
+	lib, romm, cm := setupServices(tempDir, nil, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+	writePS2Card(t, lib.GetBiosDir(), nil)
+
+	names := []string{"BASLUS-20312GAME", "BASLUS-20400SAVE", "BESLES-50000DATA", "BASLUS-20999MAIN"}
+	var wg stdsync.WaitGroup
+	for i, name := range names {
+		game := types.Game{ID: uint(i + 1), PlatformSlug: "ps2", FullPath: "ps2/game.iso"}
+		dir := filepath.Join(lib.GetRomDir(&game), "saves", corePCSX2)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create saves dir: %v", err)
+		}
+		path := filepath.Join(dir, name+".psu")
+		if err := os.WriteFile(path, makePSU(name, []byte(name)), 0o644); err != nil {
+			t.Fatalf("failed to write psu: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.importPS2Save(&game, "saves", corePCSX2, name+".psu", path); err != nil {
+				t.Errorf("importPS2Save %s failed: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	card, err := ps2mc.Open(ps2CardPath(lib.GetBiosDir()))
+	if err != nil {
+		t.Fatalf("failed to open card: %v", err)
+	}
+	saves, err := card.Saves()
+	if err != nil {
+		t.Fatalf("failed to list card: %v", err)
+	}
+	if len(saves) != len(names) {
+		t.Errorf("Expected every imported save on the card, got %+v", saves)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(filepath.Dir(ps2CardPath(lib.GetBiosDir())), "*.tmp")); len(tmps) != 0 {
+		t.Errorf("Expected no temporary cards left, got %v", tmps)
+	}
+}
@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+)
+
+// PullConflict describes a save or state that was modified locally since it was
+// last synced while a newer copy was uploaded to the server.
+type PullConflict struct {
+	GameID          uint   `json:"game_id"`
+	Type            string `json:"type"` // constants.DirSaves or constants.DirStates
+	Core            string `json:"core"`
+	Name            string `json:"name"`
+	LocalUpdatedAt  string `json:"local_updated_at"`
+	ServerUpdatedAt string `json:"server_updated_at"`
+}
+
+// ConflictResolver decides whether the server copy should replace a locally
+// modified asset. Returning true downloads the server copy.
+type ConflictResolver func(conflict PullConflict) bool
+
+// PullServerUpdates downloads the server saves and states of a game that changed
+// since they were last synced. When the local copy changed as well, resolve decides
+// which side wins; a nil resolver always keeps the local file.
+// An error is returned only when the server lists cannot be fetched.
+func (s *Service) PullServerUpdates(id uint, resolve ConflictResolver) error {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return fmt.Errorf("failed to get ROM info: %w", err)
+	}
+
+	saves, err := s.romm.GetServerSaves(id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch server saves: %w", err)
+	}
+	states, err := s.romm.GetServerStates(id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch server states: %w", err)
+	}
+
+	s.exportGameSaves(&game)
+	s.pullAssets(&game, constants.DirSaves, serverSaveAssets(saves), resolve)
+	s.pullAssets(&game, constants.DirStates, serverStateAssets(states), resolve)
+	return nil
+}
+
+func (s *Service) pullAssets(game *types.Game, subDir string, server []types.ServerAsset, resolve ConflictResolver) {
+	statuses, err := s.assetStatuses(game, subDir, server)
+	if err != nil {
+		s.ui.LogErrorf("PullServerUpdates: Failed to compute %s status for %d: %v", subDir, game.ID, err)
+		return
+	}
+
+	for i := range statuses {
+		st := &statuses[i]
+		switch st.Status {
+		case StatusServerAhead:
+		case StatusConflict:
+			conflict := PullConflict{
+				GameID:          game.ID,
+				Type:            subDir,
+				Core:            st.Core,
+				Name:            st.Name,
+				LocalUpdatedAt:  st.Local.UpdatedAt,
+				ServerUpdatedAt: st.Server.UpdatedAt,
+			}
+			if resolve == nil || !resolve(conflict) {
+				s.ui.LogInfof("PullServerUpdates: Keeping local %s %s/%s", subDir, st.Core, st.Name)
+				continue
+			}
+		default:
+			continue
+		}
+
+		remote := st.Server
+		if err := s.downloadServerAsset(game.ID, remote.ID, remote.Emulator, st.Name, remote.UpdatedAt, subDir); err != nil {
+			s.ui.LogErrorf("PullServerUpdates: Failed to download %s %s: %v", subDir, st.Name, err)
+			continue
+		}
+		s.ui.LogInfof("PullServerUpdates: Downloaded newer server %s %s/%s", subDir, remote.Emulator, st.Name)
+	}
+}
@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanServerFileName(t *testing.T) {
+	tests := map[string]string{
+		"game.srm":                                     "game.srm",
+		"game [2024-03-01_10-20-30].srm":               "game.srm",
+		"game [2024-03-01_10-20-30-2].state":           "game.state",
+		"game {@Steam Deck} [2024-03-01_10-20-30].srm": "game.srm",
+	}
+	for in, want := range tests {
+		if got := cleanServerFileName(in); got != want {
+			t.Errorf("cleanServerFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAssetKey_NormalizesCores(t *testing.T) {
+	local := assetKey("dolphin-emu/User/GC/USA/Card A", "save.gci")
+	windows := assetKey(`dolphin-emu\User\GC\USA\Card A`, "save.gci")
+	short := assetKey("Card A", "save.gci")
+	if local != windows || local != short {
+		t.Errorf("Expected identical keys, got %q, %q, %q", local, windows, short)
+	}
+}
+
+func TestPullServerUpdates(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	localSave := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(localSave, []byte("synced progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	// The 09:00 upload was last synced; since then the local file and the server both changed.
+	saves := []types.ServerSave{
+		{ServerAsset: types.ServerAsset{ID: 10, FileName: "game [2024-03-01_09-00-00].srm", Emulator: "snes9x", UpdatedAt: "2024-03-01T09:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 11, FileName: "game [2024-03-01_10-00-00].srm", Emulator: "snes9x", UpdatedAt: "2024-03-01T10:00:00Z"}},
+	}
+	states := []types.ServerState{
+		{ServerAsset: types.ServerAsset{ID: 20, FileName: "game.state", Emulator: "snes9x", UpdatedAt: "2024-03-01T10:00:00Z"}},
+	}
+	savesData, _ := json.Marshal(saves)
+	statesData, _ := json.Marshal(states)
+
+	lib, romm, cm := setupServices(tempDir, gameData, []byte("server data"))
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			switch req.URL.Path {
+			case "/api/saves":
+				body = savesData
+			case "/api/states":
+				body = statesData
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	s := New(cm, lib, romm, &MockUIProvider{})
+	s.recordSync(&game, "saves", "snes9x", "game.srm", localSave, &saves[0].ServerAsset)
+	if err := os.WriteFile(localSave, []byte("local progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	var conflicts []PullConflict
+	err := s.PullServerUpdates(1, func(c PullConflict) bool {
+		conflicts = append(conflicts, c)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("PullServerUpdates failed: %v", err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Name != "game.srm" {
+		t.Fatalf("Expected one conflict for game.srm, got %+v", conflicts)
+	}
+	if data, _ := os.ReadFile(localSave); string(data) != "local progress" {
+		t.Errorf("Expected local save to be kept, got %q", data)
+	}
+
+	statePath := filepath.Join(tempDir, "snes", "1", "states", "snes9x", "game.state")
+	if data, err := os.ReadFile(statePath); err != nil || string(data) != "server data" {
+		t.Errorf("Expected missing state to be downloaded, got %q (err %v)", data, err)
+	}
+}
+
+func TestClassifyAsset(t *testing.T) {
+	local := &types.FileItem{Name: "game.srm", Core: "snes9x", UpdatedAt: "2024-03-01T10:00:00Z"}
+	synced := &types.ServerAsset{ID: 10, UpdatedAt: "2024-03-01T09:00:00Z"}
+	newer := &types.ServerAsset{ID: 11, UpdatedAt: "2024-03-01T11:00:00Z"}
+	entry := &JournalEntry{Hash: "abc", ServerID: 10, ServerUpdatedAt: "2024-03-01T09:00:00Z"}
+
+	tests := []struct {
+		name      string
+		local     *types.FileItem
+		localHash string
+		remote    *types.ServerAsset
+		entry     *JournalEntry
+		want      SyncStatus
+	}{
+		{"local only", local, "abc", nil, nil, StatusLocalAhead},
+		{"server only", nil, "", newer, nil, StatusServerAhead},
+		{"no journal, local newer", local, "abc", synced, nil, StatusLocalAhead},
+		{"no journal, server newer", local, "abc", newer, nil, StatusServerAhead},
+		{"no journal, within tolerance", local, "abc", &types.ServerAsset{UpdatedAt: "2024-03-01T10:00:03Z"}, nil, StatusInSync},
+		{"unchanged", local, "abc", synced, entry, StatusInSync},
+		{"local changed", local, "def", synced, entry, StatusLocalAhead},
+		{"server changed", local, "abc", newer, entry, StatusServerAhead},
+		{"both changed", local, "def", newer, entry, StatusConflict},
+	}
+	for _, tt := range tests {
+		if got := classifyAsset(tt.local, tt.localHash, tt.remote, tt.entry); got != tt.want {
+			t.Errorf("%s: classifyAsset() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
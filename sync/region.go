@@ -0,0 +1,218 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dolphin memory card region folders under User/GC.
+const (
+	regionUSA = "USA"
+	regionEUR = "EUR"
+	regionJAP = "JAP"
+)
+
+var gameCubeRegions = []string{regionUSA, regionEUR, regionJAP}
+
+var gameCubePlatforms = map[string]bool{"ngc": true, "gamecube": true, "gc": true}
+
+// Offsets of the disc header's game ID in the container formats Dolphin reads.
+// WIA and RVZ keep a copy of the disc header after their 0x48-byte file header
+// and the first 0x10 bytes of the second header; CISO stores raw data after a
+// 0x8000-byte block map.
+const (
+	wiaDiscHeaderOffset  = 0x58
+	cisoDiscHeaderOffset = 0x8000
+)
+
+// readDiscGameID returns the 6-character game ID from a GameCube disc image.
+func readDiscGameID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return "", fmt.Errorf("failed to read disc header: %w", err)
+	}
+	var offset int64
+	switch {
+	case bytes.Equal(magic, []byte("WIA\x01")), bytes.Equal(magic, []byte("RVZ\x01")):
+		offset = wiaDiscHeaderOffset
+	case bytes.Equal(magic, []byte("CISO")):
+		offset = cisoDiscHeaderOffset
+	}
+
+	id := make([]byte, 6)
+	if _, err := f.ReadAt(id, offset); err != nil {
+		return "", fmt.Errorf("failed to read game ID: %w", err)
+	}
+	for _, c := range id {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return "", fmt.Errorf("no game ID found in %s", filepath.Base(path))
+		}
+	}
+	return string(id), nil
+}
+
+// regionFromGameID maps the country code, the 4th character of a game ID, to the
+// memory card folder Dolphin uses for it.
+func regionFromGameID(id string) string {
+	if len(id) < 4 {
+		return ""
+	}
+	switch id[3] {
+	case 'E', 'N':
+		return regionUSA
+	case 'J', 'K', 'Q', 'T', 'W':
+		return regionJAP
+	case 'D', 'F', 'H', 'I', 'L', 'M', 'P', 'R', 'S', 'U', 'V', 'X', 'Y':
+		return regionEUR
+	}
+	return ""
+}
+
+var regionTokens = map[string]string{
+	"usa": regionUSA, "us": regionUSA, "america": regionUSA, "ntsc-u": regionUSA,
+	"europe": regionEUR, "eur": regionEUR, "eu": regionEUR, "pal": regionEUR, "uk": regionEUR,
+	"germany": regionEUR, "france": regionEUR, "spain": regionEUR, "italy": regionEUR, "australia": regionEUR,
+	"japan": regionJAP, "jpn": regionJAP, "jp": regionJAP, "ntsc-j": regionJAP, "korea": regionJAP,
+}
+
+// regionFromNames maps region names, as listed by RomM, to a memory card folder.
+// The first recognized name wins.
+func regionFromNames(names ...string) string {
+	for _, name := range names {
+		fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/' || r == '(' || r == ')'
+		})
+		for _, f := range fields {
+			if region, ok := regionTokens[f]; ok {
+				return region
+			}
+		}
+	}
+	return ""
+}
+
+// regionFromFileName reads the region from No-Intro style tags such as "(Europe)".
+func regionFromFileName(name string) string {
+	for _, tag := range fileNameTagRe.FindAllStringSubmatch(name, -1) {
+		if region := regionFromNames(tag[1]); region != "" {
+			return region
+		}
+	}
+	return ""
+}
+
+var fileNameTagRe = regexp.MustCompile(`\(([^)]*)\)`)
+
+// gameCubeRegion returns the memory card region of a GameCube game, read from the
+// disc header of the downloaded image or else from RomM's metadata. It returns ""
+// for other platforms and when the region cannot be determined.
+func (s *Service) gameCubeRegion(game *types.Game) string {
+	if !gameCubePlatforms[getPlatformSlug(game)] {
+		return ""
+	}
+	if romPath := s.discImage(game); romPath != "" {
+		id, err := s.discs.read("gc-game-id", romPath, readDiscGameID)
+		if err == nil {
+			if region := regionFromGameID(id); region != "" {
+				return region
+			}
+		} else {
+			s.ui.LogInfof("gameCubeRegion: Could not read disc header of %s: %v", romPath, err)
+		}
+	}
+	if region := regionFromNames(game.Regions...); region != "" {
+		return region
+	}
+	if region := regionFromFileName(game.FSName); region != "" {
+		return region
+	}
+	return regionFromFileName(filepath.Base(game.FullPath))
+}
+
+// CardMigration describes a GameCube memory card file stored in the USA folder
+// for a game whose disc belongs to another region.
+type CardMigration struct {
+	Card string `json:"card"` // "Card A" or "Card B"
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PlanMemoryCardMigration lists the memory card files of a game that were placed
+// under USA, where earlier versions put every card, although the game's region is
+// EUR or JAP. Nothing is returned when the region cannot be determined.
+func (s *Service) PlanMemoryCardMigration(id uint) ([]CardMigration, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	return s.planCardMigration(&game), nil
+}
+
+func (s *Service) planCardMigration(game *types.Game) []CardMigration {
+	region := s.gameCubeRegion(game)
+	if region == "" || region == regionUSA {
+		return nil
+	}
+
+	var moves []CardMigration
+	for _, card := range []string{"Card A", "Card B"} {
+		dir := s.cardDir(game, regionUSA, card)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			moves = append(moves, CardMigration{Card: card, Name: e.Name(), From: regionUSA, To: region})
+		}
+	}
+	return moves
+}
+
+// MigrateMemoryCards moves misplaced memory card files into the game's region
+// folder. A card already present in the region folder is backed up first.
+func (s *Service) MigrateMemoryCards(id uint) ([]CardMigration, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+
+	moves := s.planCardMigration(&game)
+	for _, m := range moves {
+		src := filepath.Join(s.cardDir(&game, m.From, m.Card), m.Name)
+		destDir := s.cardDir(&game, m.To, m.Card)
+		dest := filepath.Join(destDir, m.Name)
+
+		if err := os.MkdirAll(destDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create memory card directory: %w", err)
+		}
+		if err := s.backupAsset(&game, constants.DirSaves, dolphinCardCore(m.To, m.Card), m.Name, dest); err != nil {
+			return nil, err
+		}
+		if err := moveAsset(src, dest); err != nil {
+			return nil, fmt.Errorf("failed to move %s to %s: %w", src, dest, err)
+		}
+		s.ui.LogInfof("MigrateMemoryCards: Moved %s/%s from %s to %s", m.Card, m.Name, m.From, m.To)
+	}
+	return moves, nil
+}
+
+func (s *Service) cardDir(game *types.Game, region, card string) string {
+	return filepath.Join(s.library.GetRomDir(game), constants.DirSaves, filepath.FromSlash(dolphinCardCore(region, card)))
+}
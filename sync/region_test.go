@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"encoding/json"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegionFromGameID(t *testing.T) {
+	tests := map[string]string{
+		"GALE01": regionUSA,
+		"GALP01": regionEUR,
+		"GALD01": regionEUR,
+		"GALJ01": regionJAP,
+		"GAL":    "",
+		"GALZ01": "",
+	}
+	for id, want := range tests {
+		if got := regionFromGameID(id); got != want {
+			t.Errorf("regionFromGameID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestRegionFromMetadata(t *testing.T) {
+	if got := regionFromNames("Europe"); got != regionEUR {
+		t.Errorf("Expected EUR for RomM region Europe, got %q", got)
+	}
+	if got := regionFromNames("World", "Japan"); got != regionJAP {
+		t.Errorf("Expected JAP for RomM regions World, Japan, got %q", got)
+	}
+	if got := regionFromFileName("Paladin Quest (Japan) (Rev 1).iso"); got != regionJAP {
+		t.Errorf("Expected JAP from file name tag, got %q", got)
+	}
+	if got := regionFromFileName("Paladin Quest.iso"); got != "" {
+		t.Errorf("Expected no region without tags, got %q", got)
+	}
+}
+
+func TestReadDiscGameID(t *testing.T) {
+	dir := t.TempDir()
+
+	iso := filepath.Join(dir, "game.iso")
+	if err := os.WriteFile(iso, append([]byte("GALP01"), make([]byte, 64)...), 0o644); err != nil {
+		t.Fatalf("failed to write iso: %v", err)
+	}
+	if id, err := readDiscGameID(iso); err != nil || id != "GALP01" {
+		t.Errorf("Expected GALP01 from ISO, got %q (err %v)", id, err)
+	}
+
+	rvz := make([]byte, wiaDiscHeaderOffset+0x80)
+	copy(rvz, "RVZ\x01")
+	copy(rvz[wiaDiscHeaderOffset:], "GALJ01")
+	rvzPath := filepath.Join(dir, "game.rvz")
+	if err := os.WriteFile(rvzPath, rvz, 0o644); err != nil {
+		t.Fatalf("failed to write rvz: %v", err)
+	}
+	if id, err := readDiscGameID(rvzPath); err != nil || id != "GALJ01" {
+		t.Errorf("Expected GALJ01 from RVZ, got %q (err %v)", id, err)
+	}
+
+	garbage := filepath.Join(dir, "game.gcm")
+	if err := os.WriteFile(garbage, make([]byte, 64), 0o644); err != nil {
+		t.Fatalf("failed to write gcm: %v", err)
+	}
+	if _, err := readDiscGameID(garbage); err == nil {
+		t.Error("Expected error for image without a game ID")
+	}
+}
+
+func TestMigrateMemoryCards(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "ngc", FullPath: "ngc/game.iso"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	romDir := filepath.Join(tempDir, "ngc", "1")
+	usaDir := filepath.Join(romDir, "saves", "dolphin-emu", "User", "GC", "USA", "Card A")
+	if err := os.MkdirAll(usaDir, 0o755); err != nil {
+		t.Fatalf("failed to create card dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(romDir, "game.iso"), append([]byte("GALP01"), make([]byte, 64)...), 0o644); err != nil {
+		t.Fatalf("failed to write iso: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(usaDir, "01-GALP-save.gci"), []byte("card"), 0o644); err != nil {
+		t.Fatalf("failed to write card: %v", err)
+	}
+
+	saves, err := s.GetSaves(1)
+	if err != nil || len(saves) != 1 || saves[0].Core != "dolphin-emu/User/GC/USA/Card A" {
+		t.Errorf("Expected the misplaced card to be listed under USA, got %+v (err %v)", saves, err)
+	}
+
+	plan, err := s.PlanMemoryCardMigration(1)
+	if err != nil || len(plan) != 1 || plan[0].To != regionEUR {
+		t.Fatalf("Expected one move to EUR, got %+v (err %v)", plan, err)
+	}
+	if _, err := s.MigrateMemoryCards(1); err != nil {
+		t.Fatalf("MigrateMemoryCards failed: %v", err)
+	}
+
+	eurCard := filepath.Join(romDir, "saves", "dolphin-emu", "User", "GC", "EUR", "Card A", "01-GALP-save.gci")
+	if data, err := os.ReadFile(eurCard); err != nil || string(data) != "card" {
+		t.Errorf("Expected card in EUR folder, got %q (err %v)", data, err)
+	}
+
+	saves, err = s.GetSaves(1)
+	if err != nil || len(saves) != 1 || saves[0].Core != "dolphin-emu/User/GC/EUR/Card A" {
+		t.Errorf("Expected the card to be listed under EUR, got %+v (err %v)", saves, err)
+	}
+
+	dest, err := s.prepareAssetPath(&game, "Card B", "other.gci", "saves")
+	if err != nil || dest != filepath.Join(romDir, "saves", "dolphin-emu", "User", "GC", "EUR", "Card B", "other.gci") {
+		t.Errorf("Expected server card to be placed under EUR, got %s (err %v)", dest, err)
+	}
+}
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"go-romm-sync/constants"
+	"sort"
+)
+
+// pruneServerAssets deletes the oldest server copies of the saves or states a game
+// made with one emulator, keeping the AppConfig.ServerRetention most recent ones.
+// The newest copy of every file is always kept, so pruning never removes the only
+// server copy of another save.
+func (s *Service) pruneServerAssets(id uint, subDir, core string) {
+	keep := s.config.GetConfig().ServerRetention
+	if keep <= 0 {
+		return
+	}
+	server, err := s.serverAssets(id, subDir)
+	if err != nil {
+		s.ui.LogErrorf("pruneServerAssets: %v", err)
+		return
+	}
+
+	core = canonicalCore(core)
+	matching := server[:0]
+	for _, a := range server {
+		if canonicalCore(a.Emulator) == core {
+			matching = append(matching, a)
+		}
+	}
+	if len(matching) <= keep {
+		return
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		ti, tj := parseTime(matching[i].UpdatedAt), parseTime(matching[j].UpdatedAt)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return matching[i].ID > matching[j].ID
+	})
+	newest := make(map[uint]bool)
+	for _, a := range latestServerAssets(matching) {
+		newest[a.ID] = true
+	}
+
+	var ids []uint
+	for i, a := range matching {
+		if i >= keep && !newest[a.ID] {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if subDir == constants.DirSaves {
+		err = s.romm.DeleteServerSaves(ids)
+	} else {
+		err = s.romm.DeleteServerStates(ids)
+	}
+	if err != nil {
+		s.ui.LogErrorf("pruneServerAssets: Failed to delete old %s of %d: %v", subDir, id, err)
+		return
+	}
+	s.ui.LogInfof("pruneServerAssets: Deleted %d old %s of %d for %s", len(ids), subDir, id, core)
+}
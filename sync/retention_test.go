@@ -0,0 +1,104 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadSave_PrunesOldServerCopies(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+	savesData, _ := json.Marshal([]types.ServerSave{
+		{ServerAsset: types.ServerAsset{ID: 1, FileName: "game [2024-01-01_10-00-00].srm", Emulator: "snes9x", UpdatedAt: "2024-01-01T10:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 2, FileName: "game [2024-01-02_10-00-00].srm", Emulator: "snes9x", UpdatedAt: "2024-01-02T10:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 3, FileName: "game [2024-01-03_10-00-00].srm", Emulator: "snes9x", UpdatedAt: "2024-01-03T10:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 4, FileName: "other.srm", Emulator: "snes9x", UpdatedAt: "2023-12-01T10:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 5, FileName: "game.srm", Emulator: "mgba", UpdatedAt: "2023-11-01T10:00:00Z"}},
+		{ServerAsset: types.ServerAsset{ID: 6, FileName: "game.srm", Emulator: "snes9x", UpdatedAt: "2024-01-04T10:00:00Z"}},
+	})
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	cm.Config.ServerRetention = 2
+	var deleted map[string][]uint
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			switch {
+			case req.URL.Path == "/api/saves/delete":
+				if err := json.NewDecoder(req.Body).Decode(&deleted); err != nil {
+					t.Errorf("failed to decode delete request: %v", err)
+				}
+				body = []byte("[]")
+			case req.Method == http.MethodPost:
+				body = []byte(`{"id": 6, "updated_at": "2024-01-04T10:00:00Z"}`)
+			case req.URL.Path == "/api/saves":
+				body = savesData
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+
+	// Copies 6 and 3 are the two most recent; 4 is the only copy of other.srm and 5
+	// belongs to another emulator.
+	ids := deleted["saves"]
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 1 {
+		t.Errorf("Expected saves 2 and 1 to be deleted, got %v", deleted)
+	}
+}
+
+func TestUploadSave_KeepsServerCopiesByDefault(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/api/saves/delete" {
+				t.Errorf("Expected no server saves to be deleted")
+			}
+			body := gameData
+			if req.Method == http.MethodPost {
+				body = []byte(`{"id": 6, "updated_at": "2024-01-04T10:00:00Z"}`)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+}
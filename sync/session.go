@@ -0,0 +1,209 @@
+package sync
+
+import (
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SessionSnapshot records the local saves and states of a game at launch time
+// so that the files written during a play session can be detected on exit.
+type SessionSnapshot struct {
+	GameID uint
+	files  map[sessionKey]assetStat
+}
+
+// SessionUploadResult describes the outcome of uploading one changed file.
+type SessionUploadResult struct {
+	GameID  uint   `json:"game_id"`
+	Type    string `json:"type"` // constants.DirSaves or constants.DirStates
+	Core    string `json:"core"`
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type sessionKey struct {
+	subDir string
+	core   string
+	name   string
+}
+
+type assetStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// SnapshotGame records the current saves and states of a game.
+func (s *Service) SnapshotGame(id uint) (*SessionSnapshot, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.scanSessionFiles(&game)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionSnapshot{GameID: id, files: files}, nil
+}
+
+// UploadSessionChanges compares the game's saves and states against the snapshot
+// taken before launch and uploads every new or modified file to RomM.
+// A constants.EventSaveSyncResult event is emitted for each file.
+func (s *Service) UploadSessionChanges(snap *SessionSnapshot) []SessionUploadResult {
+	game, err := s.loadGame(snap.GameID)
+	if err != nil {
+		s.ui.LogErrorf("UploadSessionChanges: Failed to get ROM info for %d: %v", snap.GameID, err)
+		return nil
+	}
+	current, err := s.scanSessionFiles(&game)
+	if err != nil {
+		s.ui.LogErrorf("UploadSessionChanges: Failed to scan files for %d: %v", snap.GameID, err)
+		return nil
+	}
+
+	return s.uploadChanges(snap.GameID, changedSessionFiles(snap.files, current), "UploadSessionChanges")
+}
+
+// UploadChangedSaves uploads the saves of a game that changed locally since they
+// were last synced, such as a save just written by a running emulator. Saves
+// written by the sync service itself match their journal entry and are never
+// uploaded back. Saves whose server copy changed too, or that were never synced
+// but have a server copy, are left for a sync to resolve instead of being
+// overwritten. A constants.EventSaveSyncResult event is emitted for each file.
+func (s *Service) UploadChangedSaves(id uint) []SessionUploadResult {
+	game, err := s.loadGame(id)
+	if err != nil {
+		s.ui.LogErrorf("UploadChangedSaves: Failed to get ROM info for %d: %v", id, err)
+		return nil
+	}
+	saves, err := s.romm.GetServerSaves(id)
+	if err != nil {
+		s.ui.LogErrorf("UploadChangedSaves: Failed to fetch server saves for %d: %v", id, err)
+		return nil
+	}
+	s.exportGameSaves(&game)
+	statuses, err := s.assetStatuses(&game, constants.DirSaves, serverSaveAssets(saves))
+	if err != nil {
+		s.ui.LogErrorf("UploadChangedSaves: %v", err)
+		return nil
+	}
+	s.journalMu.Lock()
+	j, err := s.loadJournal(&game)
+	s.journalMu.Unlock()
+	if err != nil {
+		s.ui.LogErrorf("UploadChangedSaves: %v", err)
+		return nil
+	}
+
+	var changed []sessionKey
+	for _, st := range statuses {
+		if st.Status != StatusLocalAhead {
+			if st.Status == StatusConflict {
+				s.ui.LogInfof("UploadChangedSaves: %s/%s changed on the server too, not uploading it", st.Core, st.Name)
+			}
+			continue
+		}
+		if _, tracked := j.Entries[journalKey(constants.DirSaves, st.Core, st.Name)]; !tracked && st.Server != nil {
+			continue
+		}
+		changed = append(changed, sessionKey{subDir: constants.DirSaves, core: st.Core, name: st.Name})
+	}
+	return s.uploadChanges(id, changed, "UploadChangedSaves")
+}
+
+// uploadChanges uploads the given saves and states and emits their results.
+func (s *Service) uploadChanges(id uint, keys []sessionKey, caller string) []SessionUploadResult {
+	var results []SessionUploadResult
+	for _, key := range keys {
+		result := SessionUploadResult{
+			GameID: id,
+			Type:   key.subDir,
+			Core:   key.core,
+			Name:   key.name,
+		}
+		if err := s.uploadServerAsset(id, key.core, key.name, key.subDir); err != nil {
+			s.ui.LogErrorf("%s: Failed to upload %s %s/%s: %v", caller, key.subDir, key.core, key.name, err)
+			result.Error = err.Error()
+		} else {
+			s.ui.LogInfof("%s: Uploaded %s %s/%s", caller, key.subDir, key.core, key.name)
+			result.Success = true
+		}
+		s.ui.EventsEmit(constants.EventSaveSyncResult, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *Service) scanSessionFiles(game *types.Game) (map[sessionKey]assetStat, error) {
+	romDir := s.library.GetRomDir(game)
+	biosDir := s.library.GetBiosDir()
+	platform := getPlatformSlug(game)
+
+	s.exportGameSaves(game)
+	files := make(map[sessionKey]assetStat)
+	for _, subDir := range []string{constants.DirSaves, constants.DirStates} {
+		items, err := s.listLocalFiles(game, subDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			_, path := getLocalAssetPaths(romDir, biosDir, subDir, item.Core, item.Name, platform)
+			if st, ok := statAsset(path); ok {
+				files[sessionKey{subDir: subDir, core: item.Core, name: item.Name}] = st
+			}
+		}
+	}
+	return files, nil
+}
+
+// changedSessionFiles returns the keys present in after that are missing from
+// before or whose size or modification time differ.
+func changedSessionFiles(before, after map[sessionKey]assetStat) []sessionKey {
+	var changed []sessionKey
+	for key, st := range after {
+		prev, ok := before[key]
+		if !ok || prev.size != st.size || !prev.modTime.Equal(st.modTime) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		a, b := changed[i], changed[j]
+		if a.subDir != b.subDir {
+			return a.subDir < b.subDir
+		}
+		if a.core != b.core {
+			return a.core < b.core
+		}
+		return a.name < b.name
+	})
+	return changed
+}
+
+// statAsset returns the size and latest modification time of a file, or the
+// combined size and latest modification time of a directory asset.
+func statAsset(path string) (assetStat, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return assetStat{}, false
+	}
+	if !info.IsDir() {
+		return assetStat{modTime: info.ModTime(), size: info.Size()}, true
+	}
+
+	var st assetStat
+	_ = filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		st.size += fi.Size()
+		if fi.ModTime().After(st.modTime) {
+			st.modTime = fi.ModTime()
+		}
+		return nil
+	})
+	return st, true
+}
@@ -0,0 +1,196 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChangedSessionFiles(t *testing.T) {
+	now := time.Now()
+	unchanged := sessionKey{subDir: constants.DirSaves, core: "snes9x", name: "a.srm"}
+	resized := sessionKey{subDir: constants.DirSaves, core: "snes9x", name: "b.srm"}
+	touched := sessionKey{subDir: constants.DirStates, core: "snes9x", name: "a.state"}
+	added := sessionKey{subDir: constants.DirStates, core: "snes9x", name: "a.state1"}
+
+	before := map[sessionKey]assetStat{
+		unchanged: {modTime: now, size: 10},
+		resized:   {modTime: now, size: 10},
+		touched:   {modTime: now, size: 10},
+	}
+	after := map[sessionKey]assetStat{
+		unchanged: {modTime: now, size: 10},
+		resized:   {modTime: now, size: 20},
+		touched:   {modTime: now.Add(time.Second), size: 10},
+		added:     {modTime: now, size: 5},
+	}
+
+	got := changedSessionFiles(before, after)
+	want := []sessionKey{resized, touched, added}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d changed files, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("changed[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUploadSessionChanges(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	oldSave := filepath.Join(savesDir, "old.srm")
+	if err := os.WriteFile(oldSave, []byte("old"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	var uploads []string
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.Method == "POST" {
+				uploads = append(uploads, req.URL.Path)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte("{}"))),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(gameData)),
+			}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	snap, err := s.SnapshotGame(1)
+	if err != nil {
+		t.Fatalf("SnapshotGame failed: %v", err)
+	}
+
+	// Simulate a play session writing a new save and a new state.
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("new save"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+	statesDir := filepath.Join(tempDir, "snes", "1", "states", "snes9x")
+	if err := os.MkdirAll(statesDir, 0o755); err != nil {
+		t.Fatalf("failed to create states dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(statesDir, "game.state"), []byte("state"), 0o644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	results := s.UploadSessionChanges(snap)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 upload results, got %+v", results)
+	}
+	if results[0].Type != constants.DirSaves || results[0].Name != "game.srm" || !results[0].Success {
+		t.Errorf("Unexpected save result: %+v", results[0])
+	}
+	if results[1].Type != constants.DirStates || results[1].Name != "game.state" || !results[1].Success {
+		t.Errorf("Unexpected state result: %+v", results[1])
+	}
+	if len(uploads) != 2 || uploads[0] != "/api/saves" || uploads[1] != "/api/states" {
+		t.Errorf("Expected uploads to /api/saves and /api/states, got %v", uploads)
+	}
+}
+
+func TestUploadChangedSaves(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(savePath, []byte("first"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	var server []types.ServerAsset
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			if req.Method == "POST" {
+				server = append(server, types.ServerAsset{
+					ID:        uint(len(server) + 1),
+					FileName:  "game.srm",
+					Emulator:  "snes9x",
+					UpdatedAt: fmt.Sprintf("2024-03-0%dT09:00:00Z", len(server)+1),
+				})
+				body, _ = json.Marshal(server[len(server)-1])
+			} else if req.URL.Path == "/api/saves" {
+				body, _ = json.Marshal(server)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if results := s.UploadChangedSaves(1); len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected the new save to be uploaded, got %+v", results)
+	}
+	if results := s.UploadChangedSaves(1); len(results) != 0 {
+		t.Errorf("Expected an unchanged save to be skipped, got %+v", results)
+	}
+
+	if err := os.WriteFile(savePath, []byte("second"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+	if results := s.UploadChangedSaves(1); len(results) != 1 || results[0].Name != "game.srm" {
+		t.Errorf("Expected the changed save to be uploaded, got %+v", results)
+	}
+	if len(server) != 2 {
+		t.Fatalf("Expected 2 uploads, got %d", len(server))
+	}
+
+	// Another device uploads a newer copy while the local save changes too: the
+	// conflict is left for a sync to resolve.
+	server = append(server, types.ServerAsset{ID: 9, FileName: "game.srm", Emulator: "snes9x", UpdatedAt: "2024-03-09T09:00:00Z"})
+	if err := os.WriteFile(savePath, []byte("third"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+	if results := s.UploadChangedSaves(1); len(results) != 0 {
+		t.Errorf("Expected a conflicting save not to be uploaded, got %+v", results)
+	}
+
+	// A save that was never synced is not uploaded over an existing server copy.
+	if err := os.Remove(filepath.Join(tempDir, "snes", "1", journalFileName)); err != nil {
+		t.Fatalf("failed to remove journal: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(savePath, future, future); err != nil {
+		t.Fatalf("failed to touch save: %v", err)
+	}
+	if results := s.UploadChangedSaves(1); len(results) != 0 {
+		t.Errorf("Expected an untracked save with a server copy not to be uploaded, got %+v", results)
+	}
+	if len(server) != 3 {
+		t.Errorf("Expected no further uploads, got %d server copies", len(server))
+	}
+}
@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"sort"
+)
+
+// SyncStatus classifies a save or state by comparing the local file and the newest
+// server copy against the version recorded in the sync journal.
+type SyncStatus string
+
+const (
+	// StatusInSync means neither side changed since the last sync.
+	StatusInSync SyncStatus = "in_sync"
+	// StatusLocalAhead means only the local copy changed, or it only exists locally.
+	StatusLocalAhead SyncStatus = "local_ahead"
+	// StatusServerAhead means only the server copy changed, or it only exists on the server.
+	StatusServerAhead SyncStatus = "server_ahead"
+	// StatusConflict means both copies changed since the last sync.
+	StatusConflict SyncStatus = "conflict"
+)
+
+// AssetSyncStatus is the sync state of one save or state of a game.
+type AssetSyncStatus struct {
+	Type   string             `json:"type"` // constants.DirSaves or constants.DirStates
+	Core   string             `json:"core"`
+	Name   string             `json:"name"`
+	Status SyncStatus         `json:"status"`
+	Local  *types.FileItem    `json:"local,omitempty"`
+	Server *types.ServerAsset `json:"server,omitempty"` // newest server copy
+}
+
+// GetSyncStatus returns the sync state of every local and server save and state of a game.
+func (s *Service) GetSyncStatus(id uint) ([]AssetSyncStatus, error) {
+	game, err := s.loadGame(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ROM info: %w", err)
+	}
+	saves, err := s.romm.GetServerSaves(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server saves: %w", err)
+	}
+	states, err := s.romm.GetServerStates(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server states: %w", err)
+	}
+
+	statuses, err := s.assetStatuses(&game, constants.DirSaves, serverSaveAssets(saves))
+	if err != nil {
+		return nil, err
+	}
+	stateStatuses, err := s.assetStatuses(&game, constants.DirStates, serverStateAssets(states))
+	if err != nil {
+		return nil, err
+	}
+	return append(statuses, stateStatuses...), nil
+}
+
+func (s *Service) assetStatuses(game *types.Game, subDir string, server []types.ServerAsset) ([]AssetSyncStatus, error) {
+	local, err := s.listLocalFiles(game, subDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local %s: %w", subDir, err)
+	}
+	return s.classifyAssets(game, subDir, local, server)
+}
+
+// classifyAssets compares the given local files with the server copies of a game.
+func (s *Service) classifyAssets(game *types.Game, subDir string, local []types.FileItem, server []types.ServerAsset) ([]AssetSyncStatus, error) {
+	s.journalMu.Lock()
+	j, err := s.loadJournal(game)
+	s.journalMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	localByKey := make(map[string]*types.FileItem, len(local))
+	keys := make([]string, 0, len(local))
+	for i := range local {
+		key := assetKey(local[i].Core, local[i].Name)
+		localByKey[key] = &local[i]
+		keys = append(keys, key)
+	}
+	platform := getPlatformSlug(game)
+	p := s.gamePaths(game, subDir)
+	latest := latestServerAssets(server)
+	for key, remote := range latest {
+		if _, ok := localByKey[key]; ok {
+			continue
+		}
+		// Server copies of disabled layouts, such as a whole PS2 memory card, are
+		// not offered for download.
+		if !layoutFor(platform, subDir, remote.Emulator, cleanServerFileName(remote.FileName)).isEnabled(p) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	romDir, biosDir := p.romDir, p.biosDir
+
+	statuses := make([]AssetSyncStatus, 0, len(keys))
+	for _, key := range keys {
+		st := AssetSyncStatus{Type: subDir, Local: localByKey[key]}
+		if remote, ok := latest[key]; ok {
+			st.Server = &remote
+			st.Core = remote.Emulator
+			st.Name = cleanServerFileName(remote.FileName)
+		}
+
+		if st.Local != nil {
+			st.Core = st.Local.Core
+			st.Name = st.Local.Name
+		}
+
+		var entry *JournalEntry
+		if e, ok := j.Entries[journalKey(subDir, st.Core, st.Name)]; ok {
+			entry = &e
+		}
+
+		var localHash string
+		if st.Local != nil {
+			_, path := getLocalAssetPaths(romDir, biosDir, subDir, st.Local.Core, st.Local.Name, platform)
+			if localHash, err = localAssetHash(path, entry); err != nil {
+				s.ui.LogErrorf("classifyAssets: Failed to hash %s: %v", path, err)
+			}
+		}
+
+		st.Status = classifyAsset(st.Local, localHash, st.Server, entry)
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// classifyAsset performs a three-way comparison between the local copy, the newest
+// server copy and the journal entry of the last sync. Without a journal entry the
+// modification times are compared instead.
+func classifyAsset(local *types.FileItem, localHash string, remote *types.ServerAsset, entry *JournalEntry) SyncStatus {
+	switch {
+	case remote == nil:
+		return StatusLocalAhead
+	case local == nil:
+		return StatusServerAhead
+	}
+
+	if entry == nil {
+		localTime, serverTime := parseTime(local.UpdatedAt), parseTime(remote.UpdatedAt)
+		switch {
+		case localTime.After(serverTime.Add(syncTolerance)):
+			return StatusLocalAhead
+		case serverTime.After(localTime.Add(syncTolerance)):
+			return StatusServerAhead
+		}
+		return StatusInSync
+	}
+
+	localChanged := localHash == "" || localHash != entry.Hash
+	serverChanged := !sameServerVersion(remote, entry)
+	switch {
+	case localChanged && serverChanged:
+		return StatusConflict
+	case localChanged:
+		return StatusLocalAhead
+	case serverChanged:
+		return StatusServerAhead
+	}
+	return StatusInSync
+}
+
+// sameServerVersion reports whether the server copy is the one recorded in the journal.
+func sameServerVersion(remote *types.ServerAsset, entry *JournalEntry) bool {
+	if entry.ServerID != 0 && remote.ID != entry.ServerID {
+		return false
+	}
+	return parseTime(remote.UpdatedAt).Equal(parseTime(entry.ServerUpdatedAt))
+}
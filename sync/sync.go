@@ -2,15 +2,19 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-romm-sync/config"
 	"go-romm-sync/library"
+	"go-romm-sync/romm"
 	"go-romm-sync/rommsrv"
 	"go-romm-sync/types"
 	"go-romm-sync/utils"
+	"go-romm-sync/utils/crypt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	stdsync "sync"
 	"time"
 
 	"go-romm-sync/constants"
@@ -30,14 +34,25 @@ const (
 
 // Service manages the synchronization of saves and states.
 type Service struct {
+	config  *config.ConfigManager
 	library *library.Service
 	romm    *rommsrv.Service
 	ui      types.UIProvider
+
+	journalMu stdsync.Mutex
+	cardMu    stdsync.Mutex // serializes access to the shared PS2 memory card
+
+	keyringMu   stdsync.Mutex
+	keyring     *crypt.Keyring
+	keyringPass string
+
+	discs discCache
 }
 
 // New creates a new Sync service.
-func New(lib *library.Service, romm *rommsrv.Service, ui types.UIProvider) *Service {
+func New(cfg *config.ConfigManager, lib *library.Service, romm *rommsrv.Service, ui types.UIProvider) *Service {
 	return &Service{
+		config:  cfg,
 		library: lib,
 		romm:    romm,
 		ui:      ui,
@@ -55,157 +70,88 @@ func (s *Service) GetStates(id uint) (items []types.FileItem, err error) {
 }
 
 func (s *Service) getGameFiles(id uint, subDir string) (items []types.FileItem, err error) {
-	// Try local library first for metadata
-	game, err := s.library.GetLocalGame(id)
+	game, err := s.loadGame(id)
 	if err != nil {
-		// Fallback to RomM if local metadata is missing
-		game, err = s.romm.GetRom(id)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	dirPath := filepath.Join(s.library.GetRomDir(&game), subDir)
-
-	var entries []os.DirEntry
-	if e, err := os.ReadDir(dirPath); err == nil {
-		entries = e
-	} else if !os.IsNotExist(err) {
 		return nil, err
 	}
-
-	items = s.collectCoreFiles(getPlatformSlug(&game), subDir, dirPath, entries)
-
-	if subDir == constants.DirSaves && getPlatformSlug(&game) == "ps2" {
-		pcsx2Dir := filepath.Join(s.library.GetBiosDir(), "pcsx2", "memcards")
-		if pcsx2Items := s.scanFlatCoreFiles(corePCSX2, pcsx2Dir); len(pcsx2Items) > 0 {
-			items = append(items, pcsx2Items...)
-		}
-	}
-
-	return items, nil
+	return s.listLocalFiles(&game, subDir)
 }
 
-func getPlatformSlug(game *types.Game) string {
-	if game.Platform.Slug != "" {
-		return game.Platform.Slug
-	}
-	if game.PlatformSlug != "" {
-		return game.PlatformSlug
+// loadGame returns the game metadata, preferring the local library and
+// falling back to RomM when the local metadata is missing.
+func (s *Service) loadGame(id uint) (types.Game, error) {
+	game, err := s.library.GetLocalGame(id)
+	if err == nil {
+		return game, nil
 	}
-	return ""
+	return s.romm.GetRom(id)
 }
 
-// (Deprecated/Removed handleGetFilesError logically)
-
-func (s *Service) collectCoreFiles(platformSlug, subDir, dirPath string, entries []os.DirEntry) []types.FileItem {
-	var items []types.FileItem
-	for _, entry := range entries {
-		if entry.IsDir() {
-			if entry.Name() == azaharDirName {
-				latestTime := getDirLatestModTime(filepath.Join(dirPath, entry.Name()))
-				updatedAt := latestTime.UTC().Format(time.RFC3339)
-				items = append(items, types.FileItem{
-					Name:      entry.Name(),
-					Core:      constants.CoreAzahar,
-					UpdatedAt: updatedAt,
-				})
-			} else {
-				items = append(items, s.scanCoreDir(platformSlug, subDir, dirPath, entry.Name())...)
-			}
-		}
+// gamePaths returns the locations the save layouts of a game resolve against.
+func (s *Service) gamePaths(game *types.Game, subDir string) layoutPaths {
+	return layoutPaths{
+		romDir:  s.library.GetRomDir(game),
+		biosDir: s.library.GetBiosDir(),
+		subDir:  subDir,
+		region:  s.gameCubeRegion(game),
+		serial:  s.ps2Serial(game),
 	}
-	return items
 }
 
-func (s *Service) scanCoreDir(platformSlug, subDir, dirPath, coreName string) []types.FileItem {
-	coreDir := filepath.Join(dirPath, coreName)
-	if coreName == coreDolphin {
-		return s.scanDolphinFiles(platformSlug, coreDir)
-	}
-	if platformSlug == platformPSP && (coreName == corePPSSPP || coreName == corePPSSPP_LR) {
-		return s.scanPPSSPPFiles(subDir, coreName, coreDir)
-	}
-	return s.scanFlatCoreFiles(coreName, coreDir)
+// exportGameSaves refreshes the .psu files of a PS2 game from the shared memory
+// card. Only syncing and uploading do this, so listing saves never writes files.
+func (s *Service) exportGameSaves(game *types.Game) {
+	s.exportPS2Saves(s.gamePaths(game, constants.DirSaves))
 }
 
-func (s *Service) scanPPSSPPFiles(subDir, coreName, coreDir string) []types.FileItem {
-	if subDir != constants.DirSaves {
-		return s.scanFlatCoreFiles(coreName, coreDir)
-	}
+// listLocalFiles lists the saves or states of a game as they are on disk.
+func (s *Service) listLocalFiles(game *types.Game, subDir string) (items []types.FileItem, err error) {
+	p := s.gamePaths(game, subDir)
+	platform := getPlatformSlug(game)
 
-	saveDataDir := filepath.Join(coreDir, "PSP", "SAVEDATA")
-	files, err := os.ReadDir(saveDataDir)
-	if err != nil {
-		return nil
-	}
-
-	items := make([]types.FileItem, 0, len(files))
-	for _, f := range files {
-		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+	claimed := make(map[string]bool)
+	for i := range saveLayouts {
+		l := &saveLayouts[i]
+		if !l.appliesTo(platform, subDir) || !l.isEnabled(p) {
 			continue
 		}
-		items = append(items, types.FileItem{
-			Name:      f.Name(),
-			Core:      coreName,
-			UpdatedAt: getDirLatestModTime(filepath.Join(saveDataDir, f.Name())).UTC().Format(time.RFC3339),
-		})
+		layoutItems, claims := l.scanAssets(p)
+		items = append(items, layoutItems...)
+		for _, c := range claims {
+			claimed[c] = true
+		}
 	}
-	return items
-}
 
-func (s *Service) scanDolphinFiles(platformSlug, coreDir string) []types.FileItem {
-	items := make([]types.FileItem, 0, 4) // USA, EUR, JPN, Wii
-
-	if platformSlug == platformWii {
-		wiiDir := filepath.Join(coreDir, "User", wiiDirName)
-		if info, err := os.Stat(wiiDir); err == nil && info.IsDir() {
-			latestTime := getDirLatestModTime(wiiDir)
-			updatedAt := latestTime.UTC().Format(time.RFC3339)
-			items = append(items, types.FileItem{
-				Name:      wiiDirName,
-				Core:      coreDolphin,
-				UpdatedAt: updatedAt,
-			})
-		}
-	} else {
-		gcDir := filepath.Join(coreDir, "User", "GC")
-		for _, region := range []string{"USA", "EUR", "JPN"} {
-			cardDir := filepath.Join(gcDir, region, "Card A")
-			relCore := filepath.Join(coreDolphin, "User", "GC", region, "Card A")
-			items = append(items, s.scanFlatCoreFiles(relCore, cardDir)...)
+	dirPath := filepath.Join(p.romDir, subDir)
+	entries, err := os.ReadDir(dirPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !claimed[entry.Name()] {
+			items = append(items, defaultLayout.scanDir(filepath.Join(dirPath, entry.Name()), entry.Name())...)
 		}
 	}
 
-	return items
+	return items, nil
 }
 
-func (s *Service) scanFlatCoreFiles(coreName, coreDir string) []types.FileItem {
-	files, err := os.ReadDir(coreDir)
-	if err != nil {
-		return nil
+func getPlatformSlug(game *types.Game) string {
+	if game.Platform.Slug != "" {
+		return game.Platform.Slug
 	}
-	items := make([]types.FileItem, 0, len(files))
-	for _, f := range files {
-		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
-			continue
-		}
-		info, err := f.Info()
-		updatedAt := ""
-		if err == nil {
-			updatedAt = info.ModTime().UTC().Format(time.RFC3339)
-		}
-		items = append(items, types.FileItem{
-			Name:      f.Name(),
-			Core:      coreName,
-			UpdatedAt: updatedAt,
-		})
+	if game.PlatformSlug != "" {
+		return game.PlatformSlug
 	}
-	return items
+	return ""
 }
 
-// UploadSave reads a local save file and uploads it to RomM.
+// UploadSave reads a local save file and uploads it to RomM. A PS2 save is first
+// exported from the memory card again, so the upload has its current content.
 func (s *Service) UploadSave(id uint, core, filename string) error {
+	if game, err := s.loadGame(id); err == nil && layoutFor(getPlatformSlug(&game), constants.DirSaves, core, filename).name == layoutPS2Saves {
+		s.exportGameSaves(&game)
+	}
 	return s.uploadServerAsset(id, core, filename, constants.DirSaves)
 }
 
@@ -214,24 +160,18 @@ func (s *Service) UploadState(id uint, core, filename string) error {
 	return s.uploadServerAsset(id, core, filename, constants.DirStates)
 }
 
+// getLocalAssetPaths returns the path of a local asset and the directory it must
+// stay within, as resolved by the asset's save layout.
 func getLocalAssetPaths(romDir, biosDir, subDir, core, filename, platform string) (baseDir, filePath string) {
-	if core == corePCSX2 && subDir == constants.DirSaves {
-		base := filepath.Join(biosDir, "pcsx2", "memcards")
-		return base, filepath.Join(base, filename)
-	}
-	if platform == platformPSP && (core == corePPSSPP || core == corePPSSPP_LR) && subDir == constants.DirSaves {
-		base := filepath.Join(romDir, subDir, core, "PSP", "SAVEDATA")
-		return base, filepath.Join(base, filename)
-	}
-	base := filepath.Join(romDir, subDir)
-	if core == constants.CoreAzahar && filename == azaharDirName {
-		return base, filepath.Join(base, filename)
-	}
-	if core == coreDolphin && filename == wiiDirName {
-		base = filepath.Join(base, coreDolphin, "User")
-		return base, filepath.Join(base, wiiDirName)
-	}
-	return base, filepath.Join(base, core, filename)
+	l := layoutFor(platform, subDir, core, filename)
+	p := layoutPaths{romDir: romDir, biosDir: biosDir, subDir: subDir}
+	p.core = l.resolveCore(core, p)
+	dir := l.assetDir(p)
+	baseDir = filepath.Join(romDir, subDir)
+	if l.inBios {
+		baseDir = dir
+	}
+	return baseDir, filepath.Join(dir, filename)
 }
 
 func (s *Service) uploadServerAsset(id uint, core, filename, subDir string) error {
@@ -253,40 +193,112 @@ func (s *Service) uploadServerAsset(id uint, core, filename, subDir string) erro
 		return fmt.Errorf("invalid path traversal detected")
 	}
 
-	var content []byte
-	info, statErr := os.Stat(cleanPath)
-	if statErr == nil && info.IsDir() {
-		var err error
-		content, err = archive.ZipDirToBuffer(cleanPath)
-		if err != nil {
-			return fmt.Errorf("failed to zip directory %s: %w", cleanPath, err)
-		}
-	} else {
-		content, err = os.ReadFile(cleanPath)
-		if err != nil {
-			return fmt.Errorf("failed to read local %s file: %w", subDir, err)
+	// A suspicious file must not replace a good server copy. Only an empty file,
+	// such as one left by a crashed core, is moved out of the emulator's way; a
+	// file of unexpected size may still be a live save and stays in place.
+	if _, ok := statAsset(cleanPath); ok {
+		if err := validateAsset(getPlatformSlug(&game), subDir, filename, cleanPath); err != nil {
+			err = fmt.Errorf("refusing to upload %s: %w", subDir, err)
+			if errors.Is(err, errEmptyAsset) {
+				return s.quarantineAsset(&game, filename, cleanPath, err)
+			}
+			return err
 		}
 	}
 
-	if subDir == constants.DirSaves {
-		err = s.romm.GetClient().UploadSave(id, core, filename, content)
-	} else {
-		err = s.romm.GetClient().UploadState(id, core, filename, content)
+	if remote, ok := s.serverHasContent(&game, subDir, core, filename, cleanPath); ok {
+		s.ui.LogInfof("uploadServerAsset: %s %s/%s is already on the server, skipping upload", subDir, core, filename)
+		s.recordSync(&game, subDir, core, filename, cleanPath, &remote)
+		return nil
 	}
 
+	remote, err := s.sendAsset(id, subDir, core, filename, cleanPath)
+	if errors.Is(err, romm.ErrUnauthorized) {
+		// The client cannot replay a streamed upload after logging in again, so
+		// the content is opened and sent once more with the new token.
+		s.ui.LogInfof("uploadServerAsset: Retrying %s %s/%s after RomM rejected the token", subDir, core, filename)
+		remote, err = s.sendAsset(id, subDir, core, filename, cleanPath)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Update local file time after successful upload to align with server
-	now := time.Now()
-	if err := os.Chtimes(cleanPath, now, now); err != nil {
-		s.ui.LogErrorf("uploadServerAsset: Failed to update local file time: %v", err)
+	if remote.ID == 0 || remote.UpdatedAt == "" {
+		remote = s.findLatestServerAsset(id, subDir, core, filename)
 	}
+	s.recordSync(&game, subDir, core, filename, cleanPath, &remote)
+	s.pruneServerAssets(id, subDir, core)
 
 	return nil
 }
 
+// sendAsset streams a local save or state to RomM, reporting upload progress.
+func (s *Service) sendAsset(id uint, subDir, core, filename, path string) (types.ServerAsset, error) {
+	content, total, err := openAssetContent(path)
+	if err != nil {
+		return types.ServerAsset{}, fmt.Errorf("failed to read local %s file: %w", subDir, err)
+	}
+	defer content.Close() //nolint:errcheck
+
+	progress := &uploadProgress{
+		GameID:   id,
+		Type:     subDir,
+		Core:     core,
+		Name:     filename,
+		Total:    total,
+		UI:       s.ui,
+		LastEmit: time.Now(),
+	}
+	reader, err := s.encryptAsset(io.TeeReader(content, progress))
+	if err != nil {
+		return types.ServerAsset{}, err
+	}
+	serverName := romm.TagDeviceName(filename, s.config.DeviceName())
+
+	var remote types.ServerAsset
+	if subDir == constants.DirSaves {
+		var save types.ServerSave
+		save, err = s.romm.GetClient().UploadSaveFrom(id, core, serverName, reader)
+		remote = save.ServerAsset
+	} else {
+		var state types.ServerState
+		state, err = s.romm.GetClient().UploadStateFrom(id, core, serverName, reader)
+		remote = state.ServerAsset
+	}
+	if err != nil {
+		return types.ServerAsset{}, err
+	}
+	progress.finish()
+	return remote, nil
+}
+
+// findLatestServerAsset returns the newest server copy of an asset, or an empty
+// record when it cannot be determined.
+func (s *Service) findLatestServerAsset(id uint, subDir, core, filename string) types.ServerAsset {
+	server, err := s.serverAssets(id, subDir)
+	if err != nil {
+		s.ui.LogErrorf("findLatestServerAsset: %v", err)
+		return types.ServerAsset{}
+	}
+	return latestServerAssets(server)[assetKey(core, filename)]
+}
+
+// serverAssets lists the server saves or states of a game.
+func (s *Service) serverAssets(id uint, subDir string) ([]types.ServerAsset, error) {
+	if subDir == constants.DirSaves {
+		saves, err := s.romm.GetServerSaves(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch server saves: %w", err)
+		}
+		return serverSaveAssets(saves), nil
+	}
+	states, err := s.romm.GetServerStates(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server states: %w", err)
+	}
+	return serverStateAssets(states), nil
+}
+
 // DeleteGameFile deletes a local save or state file.
 func (s *Service) DeleteGameFile(id uint, subDir, core, filename string) error {
 	game, err := s.library.GetLocalGame(id)
@@ -315,8 +327,8 @@ func (s *Service) DeleteGameFile(id uint, subDir, core, filename string) error {
 		return fmt.Errorf("failed to access file %s: %w", cleanPath, err)
 	}
 
-	err = os.RemoveAll(cleanPath)
-	if err != nil {
+	// The backup moves the file out of place, which is what deleting it means here.
+	if err := s.backupAsset(&game, subDir, core, filename, cleanPath); err != nil {
 		return fmt.Errorf("failed to delete file or directory %s: %w", cleanPath, err)
 	}
 	return nil
@@ -358,29 +370,38 @@ func (s *Service) downloadServerAsset(gameID, serverID uint, core, filename, upd
 	if filename == "" {
 		filename = serverFilename
 	}
+	filename = romm.StripDeviceTag(filename)
 
 	destPath, err := s.prepareAssetPath(&game, core, filename, subDir)
 	if err != nil {
 		return err
 	}
 
-	if err := s.saveDownloadedAsset(reader, destPath, core, filename, subDir); err != nil {
+	if err := s.saveDownloadedAsset(&game, reader, destPath, core, filename, subDir); err != nil {
+		return err
+	}
+	if err := s.importPS2Save(&game, subDir, core, filename, destPath); err != nil {
 		return err
 	}
 
 	if updatedAt != "" {
 		s.setFileTime(destPath, updatedAt)
 	}
+	s.recordSync(&game, subDir, core, filename, destPath, &types.ServerAsset{ID: serverID, UpdatedAt: updatedAt})
 
 	return nil
 }
 
-func (s *Service) saveDownloadedAsset(reader io.Reader, destPath, core, filename, subDir string) error {
-	isDirAsset := (core == constants.CoreAzahar && filename == azaharDirName) ||
-		(core == coreDolphin && filename == wiiDirName) ||
-		((core == corePPSSPP || core == corePPSSPP_LR) && subDir == constants.DirSaves)
-
-	if isDirAsset {
+// saveDownloadedAsset writes a downloaded save or state to destPath. The download
+// is validated before the current version is backed up and replaced, so a broken
+// server copy is quarantined instead of overwriting a good local file. Encrypted
+// downloads are decrypted first.
+func (s *Service) saveDownloadedAsset(game *types.Game, reader io.Reader, destPath, core, filename, subDir string) error {
+	reader, err := s.decryptAsset(reader, subDir, filename)
+	if err != nil {
+		return err
+	}
+	if layoutFor(getPlatformSlug(game), subDir, core, filename).dirAssets {
 		tmpFile, err := os.CreateTemp("", "romm_dl_*.zip")
 		if err != nil {
 			return fmt.Errorf("failed to create temp file: %w", err)
@@ -395,88 +416,77 @@ func (s *Service) saveDownloadedAsset(reader io.Reader, destPath, core, filename
 		if err := tmpFile.Close(); err != nil {
 			return fmt.Errorf("failed to close temporary zip file: %w", err)
 		}
+		if err := archive.VerifyZip(tmpFile.Name()); err != nil {
+			return s.quarantineAsset(game, filename+".zip", tmpFile.Name(), fmt.Errorf("downloaded %s %s is damaged: %w", subDir, filename, err))
+		}
 
-		_ = os.RemoveAll(destPath)
+		if err := s.backupAsset(game, subDir, core, filename, destPath); err != nil {
+			return err
+		}
 		if _, err := archive.Extract(tmpFile.Name(), destPath); err != nil {
 			return fmt.Errorf("failed to extract zip to %s: %w", destPath, err)
 		}
 		return nil
 	}
 
-	out, err := os.Create(destPath)
+	out, err := os.CreateTemp(filepath.Dir(destPath), "."+filename+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create local %s file: %w", subDir, err)
 	}
-	defer out.Close() //nolint:errcheck
+	tmpPath := out.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	_ = out.Chmod(0o644)
 
-	if _, err := io.Copy(out, reader); err != nil {
+	_, err = io.Copy(out, reader)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		return fmt.Errorf("failed to write local %s file: %w", subDir, err)
 	}
+	if err := validateAsset(getPlatformSlug(game), subDir, filename, tmpPath); err != nil {
+		return s.quarantineAsset(game, filename, tmpPath, fmt.Errorf("downloaded %s is invalid: %w", subDir, err))
+	}
+
+	if err := s.backupAsset(game, subDir, core, filename, destPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return fmt.Errorf("failed to replace local %s file: %w", subDir, err)
+	}
 	return nil
 }
 
 func (s *Service) prepareAssetPath(game *types.Game, core, filename, subDir string) (string, error) {
-	core, filename, err := s.ValidateAssetPath(core, filename)
+	destPath, err := s.assetDestPath(game, core, filename, subDir)
 	if err != nil {
 		return "", err
 	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	return destPath, nil
+}
 
-	if destDir := s.getSpecialDestDir(game, core, filename, subDir); destDir != "" {
-		if err := os.MkdirAll(destDir, 0o755); err != nil {
-			return "", fmt.Errorf("failed to create destination directory: %w", err)
-		}
-		return filepath.Join(destDir, filename), nil
+// assetDestPath returns where a downloaded save or state is written.
+func (s *Service) assetDestPath(game *types.Game, core, filename, subDir string) (string, error) {
+	core, filename, err := s.ValidateAssetPath(normalizeCore(core), filename)
+	if err != nil {
+		return "", err
 	}
 
-	baseDir := filepath.Join(s.library.GetRomDir(game), subDir)
-	core = remapCorePath(core)
-	destDir := filepath.Join(baseDir, core)
+	l := layoutFor(getPlatformSlug(game), subDir, core, filename)
+	p := s.gamePaths(game, subDir)
+	p.core = l.resolveCore(core, p)
+	baseDir := filepath.Join(p.romDir, subDir)
+	destDir := l.assetDir(p)
 
-	if !utils.IsSafePath(baseDir, destDir) {
+	if !l.inBios && !utils.IsSafePath(baseDir, destDir) {
 		return "", fmt.Errorf("invalid path traversal detected")
 	}
-
-	if err := os.MkdirAll(destDir, 0o755); err != nil {
-		return "", fmt.Errorf("failed to create destination directory: %w", err)
-	}
-
 	return filepath.Join(destDir, filename), nil
 }
 
-func (s *Service) getSpecialDestDir(game *types.Game, core, filename, subDir string) string {
-	if core == corePCSX2 && subDir == constants.DirSaves {
-		return filepath.Join(s.library.GetBiosDir(), "pcsx2", "memcards")
-	}
-	if core == constants.CoreAzahar && filename == azaharDirName {
-		return filepath.Join(s.library.GetRomDir(game), subDir)
-	}
-	if getPlatformSlug(game) == platformPSP && (core == corePPSSPP || core == corePPSSPP_LR) && subDir == constants.DirSaves {
-		return filepath.Join(s.library.GetRomDir(game), subDir, core, "PSP", "SAVEDATA")
-	}
-	return ""
-}
-
-func remapCorePath(core string) string {
-	// Remap the Dolphin "Card A" / "Card B" emulator names from RomM to the correct
-	// local nested path that the dolphin-emu RetroArch core expects.
-	// RomM stores these saves with emulator = "Card A", but locally they must live at:
-	//   saves/dolphin-emu/User/GC/{region}/Card A/
-	// We default to USA region; the file will be placed correctly for NTSC-U games.
-	//
-	// Normalize backslashes to forward slashes before extracting the base name so
-	// that saves uploaded from Windows (where the emulator field was stored with
-	// backslash separators, e.g. "dolphin-emu\User\GC\USA\Card A") are handled
-	// correctly on macOS/Linux where filepath.Base does not treat '\' as a separator.
-	coreBase := filepath.Base(strings.ReplaceAll(core, "\\", "/"))
-	switch coreBase {
-	case "Card A":
-		return filepath.Join("dolphin-emu", "User", "GC", "USA", "Card A")
-	case "Card B":
-		return filepath.Join("dolphin-emu", "User", "GC", "USA", "Card B")
-	}
-	return core
-}
-
 func (s *Service) setFileTime(destPath, updatedAt string) {
 	t, err := utils.ParseTimestamp(updatedAt)
 	if err != nil {
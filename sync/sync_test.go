@@ -62,6 +62,21 @@ func setupServices(tempDir string, gameData []byte, fileData []byte) (*library.S
 	return libSrv, rommSrv, cm
 }
 
+// routeUploads sends upload requests, which stream through the file client, to the
+// API client's mock so tests can handle all API calls in one place.
+func routeUploads(romm *rommsrv.Service) {
+	api := romm.GetClient().APIClient.Transport
+	files := romm.GetClient().FileClient.Transport
+	romm.GetClient().FileClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost {
+				return api.RoundTrip(req)
+			}
+			return files.RoundTrip(req)
+		},
+	}
+}
+
 func TestValidateAssetPath(t *testing.T) {
 	s := &Service{}
 	tests := []struct {
@@ -98,8 +113,8 @@ func TestGetSaves_Empty(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	saves, err := s.GetSaves(1)
 	if err != nil {
@@ -120,8 +135,8 @@ func TestUploadSave_PathTraversal(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	err = s.UploadSave(1, "../../etc", "passwd")
 	if err == nil {
@@ -149,8 +164,8 @@ func TestDeleteGameFile(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	err = s.DeleteGameFile(1, "saves", "snes", "game.srm")
 	if err != nil {
@@ -181,8 +196,8 @@ func TestGetSaves_WithFiles(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	saves, err := s.GetSaves(1)
 	if err != nil {
@@ -205,8 +220,8 @@ func TestDownloadServerAsset(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, fakeServerData)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, fakeServerData)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	err = s.DownloadServerSave(1, 123, "snes", "game.srm", "")
 	if err != nil {
@@ -239,7 +254,7 @@ func TestUploadSave_Success(t *testing.T) {
 	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
 
 	// Mock upload endpoint returning HTTP 200
 	romm.GetClient().APIClient.Transport = &mockTransport{
@@ -257,7 +272,8 @@ func TestUploadSave_Success(t *testing.T) {
 		},
 	}
 
-	s := New(lib, romm, &MockUIProvider{})
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	err = s.UploadSave(1, "snes", "game.srm")
 	if err != nil {
@@ -276,8 +292,8 @@ func TestGetSaves_DolphinPlatformFilter(t *testing.T) {
 	gameWii := types.Game{ID: 2, PlatformSlug: "wii", FullPath: "wii/game.wbfs"}
 	gameGCData, _ := json.Marshal(gameGC)
 
-	lib, romm, _ := setupServices(tempDir, gameGCData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameGCData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	// Create GameCube save
 	gcDir := filepath.Join(tempDir, "gamecube", "1", "saves", "dolphin-emu", "User", "GC", "USA", "Card A")
@@ -334,8 +350,8 @@ func TestGetSaves_PPSSPP(t *testing.T) {
 	game := types.Game{ID: 954, PlatformSlug: "psp", FullPath: "psp/game.iso"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	// User's path structure: saves/PPSSPP/PSP/SAVEDATA/ULUS...
 	saveName := "ULUS10374SO10000"
@@ -366,8 +382,8 @@ func TestPrepareAssetPath_PPSSPP(t *testing.T) {
 	game := types.Game{ID: 954, PlatformSlug: "psp", FullPath: "psp/game.iso"}
 	gameData, _ := json.Marshal(game)
 
-	lib, romm, _ := setupServices(tempDir, gameData, nil)
-	s := New(lib, romm, &MockUIProvider{})
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	s := New(cm, lib, romm, &MockUIProvider{})
 
 	destPath, err := s.prepareAssetPath(&game, "PPSSPP", "ULUS10374SO10000", "saves")
 	if err != nil {
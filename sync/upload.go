@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"go-romm-sync/utils/archive"
+	"io"
+	"os"
+	"time"
+)
+
+// openAssetContent opens the content uploaded for a local asset. Directories are
+// zipped on the fly while they are read. The returned size is 0 when it is not
+// known in advance.
+func openAssetContent(path string) (content io.ReadCloser, size int64, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		if err := archive.ZipDir(pw, path); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to zip directory %s: %w", path, err)) //nolint:errcheck
+			return
+		}
+		pw.Close() //nolint:errcheck
+	}()
+	return pr, 0, nil
+}
+
+// serverHasContent reports whether the local content of an asset is the version
+// recorded in the journal and that version is still the newest on the server, in
+// which case uploading it again would only create a duplicate. It returns the
+// server record in that case.
+func (s *Service) serverHasContent(game *types.Game, subDir, core, name, path string) (types.ServerAsset, bool) {
+	s.journalMu.Lock()
+	j, err := s.loadJournal(game)
+	s.journalMu.Unlock()
+	if err != nil {
+		return types.ServerAsset{}, false
+	}
+	entry, ok := j.Entries[journalKey(subDir, core, name)]
+	if !ok || entry.Hash == "" {
+		return types.ServerAsset{}, false
+	}
+	if hash, err := localAssetHash(path, &entry); err != nil || hash != entry.Hash {
+		return types.ServerAsset{}, false
+	}
+	remote := s.findLatestServerAsset(game.ID, subDir, core, name)
+	if remote.ID == 0 || !sameServerVersion(&remote, &entry) {
+		return types.ServerAsset{}, false
+	}
+	return remote, true
+}
+
+// AssetUploadProgress is emitted as constants.EventUploadProgress while a save or
+// state is uploaded. Total is 0 for directories, whose zipped size is not known
+// until the upload completes.
+type AssetUploadProgress struct {
+	GameID uint   `json:"game_id"`
+	Type   string `json:"type"`
+	Core   string `json:"core"`
+	Name   string `json:"name"`
+	Sent   int64  `json:"sent"`
+	Total  int64  `json:"total"`
+	Done   bool   `json:"done"`
+}
+
+// uploadProgress counts the bytes of an upload as they are read and emits
+// throttled progress events.
+type uploadProgress struct {
+	GameID      uint
+	Type        string
+	Core        string
+	Name        string
+	Total       int64
+	Sent        int64
+	UI          types.UIProvider
+	LastPercent float64
+	LastEmit    time.Time
+}
+
+func (p *uploadProgress) Write(b []byte) (int, error) {
+	p.Sent += int64(len(b))
+	// Throttle: emit if percentage changed significantly (>= 1%) OR it's been > 500ms
+	var percentage float64
+	if p.Total > 0 {
+		percentage = float64(p.Sent) / float64(p.Total) * 100
+	}
+	if percentage-p.LastPercent >= 1.0 || time.Since(p.LastEmit) > 500*time.Millisecond {
+		p.emit(false)
+		p.LastPercent = percentage
+	}
+	return len(b), nil
+}
+
+// finish emits the final event of a successful upload.
+func (p *uploadProgress) finish() {
+	p.Total = p.Sent
+	p.emit(true)
+}
+
+func (p *uploadProgress) emit(done bool) {
+	p.UI.EventsEmit(constants.EventUploadProgress, AssetUploadProgress{
+		GameID: p.GameID,
+		Type:   p.Type,
+		Core:   p.Core,
+		Name:   p.Name,
+		Sent:   p.Sent,
+		Total:  p.Total,
+		Done:   done,
+	})
+	p.LastEmit = time.Now()
+}
@@ -0,0 +1,240 @@
+package sync
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+	"time"
+)
+
+// recordingUI records the upload progress events it receives.
+type recordingUI struct {
+	MockUIProvider
+	mu       stdsync.Mutex
+	progress []AssetUploadProgress
+}
+
+func (u *recordingUI) EventsEmit(eventName string, args ...interface{}) {
+	if eventName != constants.EventUploadProgress {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.progress = append(u.progress, args[0].(AssetUploadProgress))
+}
+
+func TestUploadSave_StreamsDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "3ds", FullPath: "3ds/game.3ds"}
+	gameData, _ := json.Marshal(game)
+
+	azaharDir := filepath.Join(tempDir, "3ds", "1", "saves", azaharDirName)
+	if err := os.MkdirAll(filepath.Join(azaharDir, "sdmc"), 0o755); err != nil {
+		t.Fatalf("failed to create save dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(azaharDir, "sdmc", "save.bin"), bytes.Repeat([]byte("x"), 1<<20), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	var uploaded []string
+	romm.GetClient().FileClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			_, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+			part, err := multipart.NewReader(req.Body, params["boundary"]).NextPart()
+			if err != nil {
+				t.Errorf("failed to read upload part: %v", err)
+			} else {
+				data, _ := io.ReadAll(part)
+				zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Errorf("expected a zip upload: %v", err)
+				} else {
+					for _, f := range zr.File {
+						uploaded = append(uploaded, f.Name)
+					}
+				}
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte("{}")))}, nil
+		},
+	}
+	ui := &recordingUI{}
+	s := New(cm, lib, romm, ui)
+
+	if err := s.UploadSave(1, constants.CoreAzahar, azaharDirName); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "sdmc/save.bin" {
+		t.Errorf("Expected the zipped directory to be uploaded, got %v", uploaded)
+	}
+
+	if len(ui.progress) == 0 {
+		t.Fatal("Expected upload progress events")
+	}
+	last := ui.progress[len(ui.progress)-1]
+	if !last.Done || last.Sent == 0 || last.Sent != last.Total || last.Name != azaharDirName {
+		t.Errorf("Unexpected final progress event: %+v", last)
+	}
+	for _, p := range ui.progress[:len(ui.progress)-1] {
+		if p.Done || p.Total != 0 {
+			t.Errorf("Expected intermediate events of unknown total, got %+v", p)
+		}
+	}
+}
+
+func TestUploadSave_SkipsContentOnServer(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "3ds", FullPath: "3ds/game.3ds"}
+	gameData, _ := json.Marshal(game)
+
+	azaharDir := filepath.Join(tempDir, "3ds", "1", "saves", azaharDirName)
+	savePath := filepath.Join(azaharDir, "save.bin")
+	if err := os.MkdirAll(azaharDir, 0o755); err != nil {
+		t.Fatalf("failed to create save dir: %v", err)
+	}
+	if err := os.WriteFile(savePath, []byte("progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	remote := types.ServerAsset{ID: 5, FileName: azaharDirName, Emulator: constants.CoreAzahar, UpdatedAt: "2024-03-01T10:00:00Z"}
+	savesData, _ := json.Marshal([]types.ServerSave{{ServerAsset: remote}})
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	uploads := 0
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			switch {
+			case req.Method == http.MethodPost:
+				uploads++
+				body = []byte("{}")
+			case req.URL.Path == "/api/saves":
+				body = savesData
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+	s.recordSync(&game, constants.DirSaves, constants.CoreAzahar, azaharDirName, azaharDir, &remote)
+
+	// Rewriting the same content only changes the modification time.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(savePath, later, later); err != nil {
+		t.Fatalf("failed to touch save: %v", err)
+	}
+	if err := s.UploadSave(1, constants.CoreAzahar, azaharDirName); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+	if uploads != 0 {
+		t.Errorf("Expected unchanged content not to be uploaded, got %d uploads", uploads)
+	}
+
+	if err := os.WriteFile(savePath, []byte("more progress"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+	if err := s.UploadSave(1, constants.CoreAzahar, azaharDirName); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+	if uploads != 1 {
+		t.Errorf("Expected changed content to be uploaded, got %d uploads", uploads)
+	}
+}
+
+func TestUploadSave_TagsDevice(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("save"), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	cm.Config.DeviceName = "Steam Deck"
+	var uploaded string
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			body := gameData
+			if req.Method == http.MethodPost {
+				if _, header, err := req.FormFile("saveFile"); err == nil {
+					uploaded = header.Filename
+				}
+				body = []byte("{}")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err != nil {
+		t.Fatalf("UploadSave failed: %v", err)
+	}
+	if uploaded != "game {@Steam Deck}.srm" {
+		t.Errorf("Expected the upload to carry the device name, got %q", uploaded)
+	}
+}
+
+func TestUploadSave_RetriesAfterReauth(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, FullPath: "snes/game.sfc"}
+	gameData, _ := json.Marshal(game)
+
+	savesDir := filepath.Join(tempDir, "snes", "1", "saves", "snes9x")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savesDir, "game.srm"), []byte("save data"), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	var attempts int
+	var uploaded string
+	romm.GetClient().FileClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			attempts++
+			_, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+			part, err := multipart.NewReader(req.Body, params["boundary"]).NextPart()
+			if err != nil {
+				t.Errorf("failed to read upload part: %v", err)
+				return nil, err
+			}
+			data, _ := io.ReadAll(part)
+			if req.Header.Get("Authorization") != "Bearer fresh" {
+				return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			}
+			uploaded = string(data)
+			body := []byte(`{"id": 5, "updated_at": "2024-03-01T09:00:00Z"}`)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		},
+	}
+	romm.SetReauthenticator(func() error {
+		romm.SetClientToken("fresh")
+		return nil
+	})
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.UploadSave(1, "snes9x", "game.srm"); err != nil {
+		t.Fatalf("Expected the upload to be sent again after logging in, got %v", err)
+	}
+	if attempts != 2 || uploaded != "save data" {
+		t.Errorf("Expected the full save on the second attempt, got %d attempts and %q", attempts, uploaded)
+	}
+}
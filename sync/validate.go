@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"go-romm-sync/constants"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// quarantineDirName is the per-game directory, next to metadata.json, holding saves
+// and states that failed validation.
+const quarantineDirName = ".quarantine"
+
+// errEmptyAsset reports a save or state without content, such as one left by a
+// crashed core.
+var errEmptyAsset = errors.New("is empty")
+
+// sramPlatforms are the platforms whose .srm files are raw cartridge SRAM dumps.
+var sramPlatforms = map[string]bool{"gb": true, "gbc": true, "gba": true}
+
+// sramSizes are the SRAM and flash sizes of Game Boy and Game Boy Advance cartridges,
+// including the 256 bytes of MBC2 built-in RAM.
+var sramSizes = []int64{256, 512, 2 << 10, 8 << 10, 32 << 10, 64 << 10, 128 << 10}
+
+// rtcFooterSizes are the real-time clock footers emulators append to SRAM dumps:
+// 16 bytes for mGBA, 44 or 48 bytes for Game Boy emulators.
+var rtcFooterSizes = []int64{0, 16, 44, 48}
+
+// validateAsset checks that a save or state looks like real content before it is
+// uploaded or installed: it must not be empty, and Game Boy and Game Boy Advance
+// SRAM must have a size a cartridge can have.
+func validateAsset(platform, subDir, name, path string) error {
+	st, ok := statAsset(path)
+	if !ok {
+		return fmt.Errorf("%s does not exist", name)
+	}
+	if st.size == 0 {
+		return fmt.Errorf("%s %w", name, errEmptyAsset)
+	}
+	if subDir == constants.DirSaves && sramPlatforms[platform] && strings.EqualFold(filepath.Ext(name), ".srm") {
+		if !validSRAMSize(st.size) {
+			return fmt.Errorf("%s has %d bytes, which is not a %s SRAM size", name, st.size, platform)
+		}
+	}
+	return nil
+}
+
+func validSRAMSize(size int64) bool {
+	for _, footer := range rtcFooterSizes {
+		for _, s := range sramSizes {
+			if size == s+footer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quarantineAsset moves a save or state that failed validation out of the way, into
+// the game's quarantine directory, and returns an error explaining why.
+func (s *Service) quarantineAsset(game *types.Game, name, path string, reason error) error {
+	dir := filepath.Join(s.library.GetRomDir(game), quarantineDirName, time.Now().UTC().Format(backupIDLayout))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("%w; failed to create quarantine directory: %v", reason, err)
+	}
+	dest := filepath.Join(dir, name)
+	if err := moveAsset(path, dest); err != nil {
+		return fmt.Errorf("%w; failed to quarantine %s: %v", reason, path, err)
+	}
+	s.ui.LogErrorf("quarantineAsset: %v, moved to %s", reason, dest)
+	return fmt.Errorf("%w, moved to quarantine at %s", reason, dest)
+}
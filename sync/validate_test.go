@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-romm-sync/types"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateAsset(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		platform, subDir, name string
+		size                   int
+		valid                  bool
+	}{
+		{"snes", "saves", "empty.srm", 0, false},
+		{"snes", "saves", "odd.srm", 1234, true},
+		{"gba", "saves", "flash.srm", 128 << 10, true},
+		{"gb", "saves", "mbc2.srm", 256, true},
+		{"gba", "saves", "rtc.srm", 32<<10 + 16, true},
+		{"gb", "saves", "rtc-gb.srm", 8<<10 + 48, true},
+		{"gba", "saves", "short.srm", 1000, false},
+		{"gba", "states", "state.state1", 1000, true},
+	}
+	for _, tt := range tests {
+		path := write(tt.name, tt.size)
+		err := validateAsset(tt.platform, tt.subDir, tt.name, path)
+		if (err == nil) != tt.valid {
+			t.Errorf("validateAsset(%s, %s, %s) = %v, want valid %v", tt.platform, tt.subDir, tt.name, err, tt.valid)
+		}
+	}
+
+	if err := validateAsset("psp", "saves", "empty", t.TempDir()); err == nil {
+		t.Error("Expected an empty save directory to be rejected")
+	}
+}
+
+func TestUploadSave_QuarantinesEmptySave(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "gba", "1", "saves", "mgba")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(savePath, nil, 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, PlatformSlug: "gba", FullPath: "gba/game.gba"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost {
+				t.Errorf("Expected no upload of an empty save")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(gameData))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	err := s.UploadSave(1, "mgba", "game.srm")
+	if err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Fatalf("Expected an empty save error, got %v", err)
+	}
+	if _, err := os.Stat(savePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty save to be moved out of the saves directory")
+	}
+	quarantined, _ := filepath.Glob(filepath.Join(tempDir, "gba", "1", quarantineDirName, "*", "game.srm"))
+	if len(quarantined) != 1 {
+		t.Errorf("Expected the save in quarantine, got %v", quarantined)
+	}
+}
+
+func TestUploadSave_KeepsSaveOfUnexpectedSize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	savesDir := filepath.Join(tempDir, "gba", "1", "saves", "mgba")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	savePath := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(savePath, make([]byte, 1000), 0o644); err != nil {
+		t.Fatalf("failed to write save file: %v", err)
+	}
+
+	game := types.Game{ID: 1, PlatformSlug: "gba", FullPath: "gba/game.gba"}
+	gameData, _ := json.Marshal(game)
+
+	lib, romm, cm := setupServices(tempDir, gameData, nil)
+	romm.GetClient().APIClient.Transport = &mockTransport{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost {
+				t.Errorf("Expected no upload of a save with an unexpected size")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(gameData))}, nil
+		},
+	}
+	routeUploads(romm)
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	err := s.UploadSave(1, "mgba", "game.srm")
+	if err == nil || !strings.Contains(err.Error(), "not a gba SRAM size") {
+		t.Fatalf("Expected an SRAM size error, got %v", err)
+	}
+	if _, err := os.Stat(savePath); err != nil {
+		t.Errorf("Expected the save to stay in place: %v", err)
+	}
+	if quarantined, _ := filepath.Glob(filepath.Join(tempDir, "gba", "1", quarantineDirName, "*")); len(quarantined) != 0 {
+		t.Errorf("Expected nothing in quarantine, got %v", quarantined)
+	}
+}
+
+func TestDownloadServerSave_KeepsLocalWhenInvalid(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "gba", FullPath: "gba/game.gba"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, []byte("truncated"))
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	savesDir := filepath.Join(tempDir, "gba", "1", "saves", "mgba")
+	if err := os.MkdirAll(savesDir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	local := bytes.Repeat([]byte{1}, 32<<10)
+	savePath := filepath.Join(savesDir, "game.srm")
+	if err := os.WriteFile(savePath, local, 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+
+	if err := s.DownloadServerSave(1, 10, "mgba", "game.srm", ""); err == nil {
+		t.Fatal("Expected the download to be rejected")
+	}
+	if data, _ := os.ReadFile(savePath); !bytes.Equal(data, local) {
+		t.Errorf("Expected the local save to be kept, got %d bytes", len(data))
+	}
+	if backups, _ := s.ListBackups(1); len(backups) != 0 {
+		t.Errorf("Expected no backup for a rejected download, got %+v", backups)
+	}
+	quarantined, _ := filepath.Glob(filepath.Join(tempDir, "gba", "1", quarantineDirName, "*", "game.srm"))
+	if len(quarantined) != 1 {
+		t.Errorf("Expected the download in quarantine, got %v", quarantined)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(savesDir, ".*")); len(leftovers) != 0 {
+		t.Errorf("Expected no temporary files, got %v", leftovers)
+	}
+}
+
+func TestDownloadServerSave_RejectsTruncatedZip(t *testing.T) {
+	tempDir := t.TempDir()
+	game := types.Game{ID: 1, PlatformSlug: "psp", FullPath: "psp/game.iso"}
+	gameData, _ := json.Marshal(game)
+	lib, romm, cm := setupServices(tempDir, gameData, []byte("PK\x03\x04truncated"))
+	s := New(cm, lib, romm, &MockUIProvider{})
+
+	if err := s.DownloadServerSave(1, 10, "PPSSPP", "ULUS10041DATA", ""); err == nil || !strings.Contains(err.Error(), "damaged") {
+		t.Fatalf("Expected a damaged zip error, got %v", err)
+	}
+	quarantined, _ := filepath.Glob(filepath.Join(tempDir, "psp", "1", quarantineDirName, "*", "ULUS10041DATA.zip"))
+	if len(quarantined) != 1 {
+		t.Errorf("Expected the zip in quarantine, got %v", quarantined)
+	}
+}
@@ -2,18 +2,27 @@ package types
 
 // AppConfig holds all application settings
 type AppConfig struct {
-	RommHost            string            `json:"romm_host"`            // IP address or url of the RomM server
-	Username            string            `json:"username"`             // Username for the RomM server
-	Password            string            `json:"password"`             // Password for the RomM server
-	LibraryPath         string            `json:"library_path"`         // Where to download ROMs
-	RetroArchPath       string            `json:"retroarch_path"`       // Root folder of RA
-	RetroArchExecutable string            `json:"retroarch_executable"` // "retroarch.exe"
-	CheevosUsername     string            `json:"cheevos_username"`
-	CheevosPassword     string            `json:"cheevos_password"`
-	LastUsedCores       map[string]string `json:"last_used_cores"`   // Platform slug -> Core base name
-	PlatformFirmware    map[string]uint   `json:"platform_firmware"` // Platform slug -> Selected Firmware ID
-	OfflineMode         bool              `json:"offline_mode"`      // Enable offline mode
-	ClientToken         string            `json:"client_token"`      // Persistent token for the RomM server
+	RommHost             string            `json:"romm_host"`            // IP address or url of the RomM server
+	Username             string            `json:"username"`             // Username for the RomM server
+	Password             string            `json:"password"`             // Password for the RomM server
+	LibraryPath          string            `json:"library_path"`         // Where to download ROMs
+	RetroArchPath        string            `json:"retroarch_path"`       // Root folder of RA
+	RetroArchExecutable  string            `json:"retroarch_executable"` // "retroarch.exe"
+	CheevosUsername      string            `json:"cheevos_username"`
+	CheevosPassword      string            `json:"cheevos_password"`
+	LastUsedCores        map[string]string `json:"last_used_cores"`        // Platform slug -> Core base name
+	PlatformFirmware     map[string]uint   `json:"platform_firmware"`      // Platform slug -> Selected Firmware ID
+	OfflineMode          bool              `json:"offline_mode"`           // Enable offline mode
+	ClientToken          string            `json:"client_token"`           // Persistent token for the RomM server
+	BackupGenerations    int               `json:"backup_generations"`     // Backups kept per save/state; 0 uses the default
+	WatchSaves           bool              `json:"watch_saves"`            // Upload saves in the background as they change
+	WatchDebounceSeconds int               `json:"watch_debounce_seconds"` // Quiet period before a changed save is uploaded; 0 uses the default
+	ServerRetention      int               `json:"server_retention"`       // Server copies kept per game and emulator after an upload; 0 or less keeps all
+	DeviceName           string            `json:"device_name"`            // Tags uploaded saves and states; empty uses the hostname
+	EncryptSaves         bool              `json:"encrypt_saves"`          // Encrypt saves and states before they are uploaded
+	EncryptionPassphrase string            `json:"encryption_passphrase"`  // Derives the key of encrypted saves and states
+	DeclinedCardMoves    []uint            `json:"declined_card_moves"`    // Games whose memory card migration the user declined
+	DeclinedCoreSwitches map[uint][]string `json:"declined_core_switches"` // Game ID -> "from>to" core switches whose save copy the user declined
 }
 
 // UIProvider defines standard UI logging and event emission behaviors.
@@ -8,20 +8,46 @@ type LibraryResult[T any] struct {
 
 // Game represents a ROM/Game from the RomM library
 type Game struct {
-	ID                  uint     `json:"id"`
-	Title               string   `json:"name"` // API returns "name", we map it to Title
-	RomID               uint     `json:"rom_id"`
-	CoverURL            string   `json:"url_cover"`
-	FullPath            string   `json:"full_path"`
-	Summary             string   `json:"summary"`
-	Genres              []string `json:"genres"`
-	HasSaves            bool     `json:"has_saves"` // Simplified for now, though API might return a list
-	FileSize            int64    `json:"fs_size_bytes"`
-	PlatformID          uint     `json:"platform_id"`
-	PlatformSlug        string   `json:"platform_slug"`
-	PlatformDisplayName string   `json:"platform_display_name"`
-	Platform            Platform `json:"platform"`
-	FSName              string   `json:"fs_name"`
+	ID                  uint      `json:"id"`
+	Title               string    `json:"name"` // API returns "name", we map it to Title
+	RomID               uint      `json:"rom_id"`
+	CoverURL            string    `json:"url_cover"`
+	FullPath            string    `json:"full_path"`
+	Summary             string    `json:"summary"`
+	Genres              []string  `json:"genres"`
+	HasSaves            bool      `json:"has_saves"` // Simplified for now, though API might return a list
+	FileSize            int64     `json:"fs_size_bytes"`
+	PlatformID          uint      `json:"platform_id"`
+	PlatformSlug        string    `json:"platform_slug"`
+	PlatformDisplayName string    `json:"platform_display_name"`
+	Platform            Platform  `json:"platform"`
+	FSName              string    `json:"fs_name"`
+	CRCHash             string    `json:"crc_hash"` // hashes of the file RomM serves, in hex
+	MD5Hash             string    `json:"md5_hash"`
+	SHA1Hash            string    `json:"sha1_hash"`
+	Regions             []string  `json:"regions"`
+	Files               []RomFile `json:"files"` // the files of the ROM, when RomM stores it as a folder
+	HasMultipleFiles    bool      `json:"has_multiple_files"`
+	HasNestedSingleFile bool      `json:"has_nested_single_file"` // a folder holding a single file
+	Multi               bool      `json:"multi"`                  // has_multiple_files in older RomM versions
+}
+
+// IsFolder reports whether RomM stores the ROM as a folder of files, which it
+// serves as a zip, rather than as a single file.
+func (g *Game) IsFolder() bool {
+	return g.HasMultipleFiles || g.HasNestedSingleFile || g.Multi
+}
+
+// RomFile is one file of a ROM, such as a disc, a track, or an update
+type RomFile struct {
+	ID       uint   `json:"id"`
+	FileName string `json:"file_name"`
+	FullPath string `json:"full_path"`
+	FileSize int64  `json:"file_size_bytes"`
+	Category string `json:"category"` // e.g. "dlc" or "update"; empty for the game itself
+	CRCHash  string `json:"crc_hash"`
+	MD5Hash  string `json:"md5_hash"`
+	SHA1Hash string `json:"sha1_hash"`
 }
 
 // FileItem represents a local save or state file
@@ -39,6 +65,7 @@ type ServerAsset struct {
 	Emulator  string `json:"emulator"`
 	UpdatedAt string `json:"updated_at"` // ISO8601 string
 	FileSize  int64  `json:"file_size_bytes"`
+	Device    string `json:"device,omitempty"` // device that uploaded the copy, parsed from FileName
 }
 
 // ServerSave is a wrapper for ServerAsset representing a save file on the RomM server
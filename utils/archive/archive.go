@@ -2,12 +2,16 @@ package archive
 
 import (
 	"archive/zip"
-	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bodgit/sevenzip"
 	"github.com/nwaples/rardecode/v2"
@@ -22,43 +26,115 @@ const (
 	extBin = ".bin"
 )
 
-// ZipDirToBuffer zips a directory and returns its content as a byte slice.
-func ZipDirToBuffer(dirPath string) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	zw := zip.NewWriter(buf)
+// zipModTime is stored for every file in directory archives; 1980-01-01 is the
+// earliest date the zip format can represent.
+var zipModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ZipDir writes a zip archive of a directory to w, streaming one file at a time so
+// memory use does not depend on the size of the directory.
+// The archive is deterministic: entries are written in lexical order with fixed
+// timestamps, permissions and compression level, so unchanged directories always
+// produce the same bytes.
+func ZipDir(w io.Writer, dirPath string) error {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, flate.DefaultCompression)
+	})
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	err := walkFiles(dirPath, func(path, relPath string, _ fs.FileInfo) error {
+		header := &zip.FileHeader{
+			Name:     relPath,
+			Method:   zip.Deflate,
+			Modified: zipModTime,
 		}
-		if info.IsDir() {
-			return nil
-		}
-		relPath, err := filepath.Rel(dirPath, path)
+		header.SetMode(0o644)
+		f, err := zw.CreateHeader(header)
 		if err != nil {
 			return err
 		}
-		f, err := zw.Create(relPath)
+		src, err := os.Open(path)
 		if err != nil {
 			return err
 		}
+		defer func() { _ = src.Close() }()
+		_, err = io.Copy(f, src)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// HashDir returns the hex SHA-256 of the relative paths, sizes and contents of
+// every file in a directory. Like ZipDir it ignores timestamps and permissions, so
+// it identifies a directory asset by content alone.
+func HashDir(dirPath string) (string, error) {
+	h := sha256.New()
+	err := walkFiles(dirPath, func(path, relPath string, info fs.FileInfo) error {
+		fmt.Fprintf(h, "%s\x00%d\x00", relPath, info.Size())
 		src, err := os.Open(path)
 		if err != nil {
 			return err
 		}
 		defer func() { _ = src.Close() }()
-		_, err = io.Copy(f, src)
+		_, err = io.Copy(h, src)
 		return err
 	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
 
+// VerifyZip reads every entry of a zip archive, checking its CRC, and fails when
+// the archive is truncated or corrupt. An archive without files, such as one of an
+// empty save folder, is intact.
+func VerifyZip(path string) error {
+	r, err := zip.OpenReader(path)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to open zip: %w", err)
 	}
-	if err := zw.Close(); err != nil {
-		return nil, err
+	defer func() { _ = r.Close() }()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
 	}
+	return nil
+}
 
-	return buf.Bytes(), nil
+// walkFiles calls fn for every regular file below dirPath in lexical order, with
+// its path relative to dirPath using forward slashes.
+func walkFiles(dirPath string, fn func(path, relPath string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(relPath), info)
+	})
 }
 
 // Extract extracts all files from an archive to the destination directory.
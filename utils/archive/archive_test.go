@@ -2,11 +2,14 @@ package archive
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/base64"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // testData7z is a minimal 7z archive containing game.cue and game.bin, used for testing.
@@ -221,3 +224,155 @@ func TestExtractCueBin_Rar_Negative(t *testing.T) {
 		t.Error("expected extracted to be false for RAR without .cue/.bin pair")
 	}
 }
+
+func TestZipDir(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"a.bin": "first", filepath.Join("sub", "b.bin"): "second"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ZipDir(&buf, srcDir); err != nil {
+		t.Fatalf("ZipDir failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	if len(zr.File) != len(files) {
+		t.Fatalf("Expected %d files in zip, got %d", len(files), len(zr.File))
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if want := files[filepath.FromSlash(f.Name)]; string(data) != want {
+			t.Errorf("%s: expected %q, got %q", f.Name, want, data)
+		}
+	}
+}
+
+func TestZipDir_Deterministic(t *testing.T) {
+	srcDir := t.TempDir()
+	for _, name := range []string{"b.bin", "a.bin", filepath.Join("sub", "c.bin")} {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content of "+name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zipAndHash := func() ([]byte, string) {
+		var buf bytes.Buffer
+		if err := ZipDir(&buf, srcDir); err != nil {
+			t.Fatalf("ZipDir failed: %v", err)
+		}
+		hash, err := HashDir(srcDir)
+		if err != nil {
+			t.Fatalf("HashDir failed: %v", err)
+		}
+		return buf.Bytes(), hash
+	}
+	zip1, hash1 := zipAndHash()
+
+	// Touching files and changing permissions must not change the archive.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(srcDir, "a.bin"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(srcDir, "b.bin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	zip2, hash2 := zipAndHash()
+	if !bytes.Equal(zip1, zip2) {
+		t.Error("Expected identical archives for unchanged content")
+	}
+	if hash1 != hash2 {
+		t.Errorf("Expected identical hashes, got %s and %s", hash1, hash2)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zip1), int64(len(zip1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "a.bin,b.bin,sub/c.bin" {
+		t.Errorf("Expected sorted slash-separated entries, got %v", names)
+	}
+
+	if err := os.WriteFile(filepath.Join(srcDir, "a.bin"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, hash3 := zipAndHash(); hash3 == hash1 {
+		t.Error("Expected the hash to change with the content")
+	}
+}
+
+func TestVerifyZip(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "save.bin"), bytes.Repeat([]byte("save data "), 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := ZipDir(&buf, srcDir); err != nil {
+		t.Fatalf("ZipDir failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.zip")
+	truncated := filepath.Join(dir, "truncated.zip")
+	empty := filepath.Join(dir, "empty.zip")
+	dirsOnly := filepath.Join(dir, "dirs.zip")
+	if err := os.WriteFile(good, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(truncated, buf.Bytes()[:buf.Len()/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var emptyBuf bytes.Buffer
+	if err := ZipDir(&emptyBuf, t.TempDir()); err != nil {
+		t.Fatalf("ZipDir failed: %v", err)
+	}
+	if err := os.WriteFile(empty, emptyBuf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var dirsBuf bytes.Buffer
+	zw := zip.NewWriter(&dirsBuf)
+	if _, err := zw.Create("SAVEDATA/ULUS10041/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dirsOnly, dirsBuf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyZip(good); err != nil {
+		t.Errorf("Expected a complete zip to verify, got %v", err)
+	}
+	if err := VerifyZip(truncated); err == nil {
+		t.Error("Expected a truncated zip to fail")
+	}
+	if err := VerifyZip(empty); err != nil {
+		t.Errorf("Expected an empty zip to verify, got %v", err)
+	}
+	if err := VerifyZip(dirsOnly); err != nil {
+		t.Errorf("Expected a zip of only directories to verify, got %v", err)
+	}
+}
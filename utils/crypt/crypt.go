@@ -0,0 +1,316 @@
+// Package crypt encrypts save and state payloads with a key derived from a
+// passphrase, so they can be stored on a server that is not trusted with them.
+//
+// An encrypted payload starts with a header holding the key derivation parameters
+// and a key check value, followed by chunks of AES-256-GCM sealed data. Chunks are
+// numbered and the last one is marked, so reordered or truncated payloads fail to
+// decrypt, and the header is authenticated as part of every chunk.
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// magic identifies encrypted payloads.
+	magic       = "RSYNCENC"
+	version     = 1
+	saltSize    = 16
+	checkSize   = 16
+	prefixSize  = 7
+	keySize     = 32
+	chunkSize   = 64 << 10
+	tagSize     = 16
+	minIterates = 100_000
+
+	// HeaderSize is the size of the header of an encrypted payload.
+	HeaderSize = len(magic) + 1 + 4 + saltSize + checkSize + prefixSize
+)
+
+// Iterations is the PBKDF2-SHA256 work factor used for new keys. Payloads record
+// the value they were encrypted with.
+var Iterations = 600_000
+
+var (
+	// ErrWrongKey is returned when a payload was encrypted with another passphrase.
+	ErrWrongKey = errors.New("wrong encryption passphrase")
+	// ErrCorrupt is returned when a payload was modified or truncated.
+	ErrCorrupt = errors.New("encrypted data is damaged")
+)
+
+// IsEncrypted reports whether data starts with the header of an encrypted payload.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Detect peeks at the start of r and reports whether it is an encrypted payload.
+// The returned reader yields the whole of r, including the peeked bytes.
+func Detect(r io.Reader) (io.Reader, bool, error) {
+	br := bufio.NewReaderSize(r, HeaderSize)
+	head, err := br.Peek(len(magic))
+	if err != nil && err != io.EOF {
+		return br, false, err
+	}
+	return br, IsEncrypted(head), nil
+}
+
+type key struct {
+	salt       []byte
+	iterations uint32
+	aead       cipher.AEAD
+	check      []byte
+}
+
+// Keyring derives keys from a passphrase. Derivation is deliberately slow, so keys
+// are cached: new payloads all use one key with a random salt, and keys of
+// decrypted payloads are kept by salt.
+type Keyring struct {
+	passphrase string
+
+	mu      sync.Mutex
+	current *key
+	keys    map[string]*key
+}
+
+// NewKeyring returns a keyring for a passphrase.
+func NewKeyring(passphrase string) *Keyring {
+	return &Keyring{passphrase: passphrase, keys: make(map[string]*key)}
+}
+
+func (k *Keyring) derive(salt []byte, iterations uint32) (*key, error) {
+	cacheKey := fmt.Sprintf("%x/%d", salt, iterations)
+	if cached, ok := k.keys[cacheKey]; ok {
+		return cached, nil
+	}
+	material, err := pbkdf2.Key(sha256.New, k.passphrase, salt, int(iterations), 2*keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(material[:keySize])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, material[keySize:])
+	mac.Write([]byte(magic))
+	derived := &key{salt: salt, iterations: iterations, aead: aead, check: mac.Sum(nil)[:checkSize]}
+	k.keys[cacheKey] = derived
+	return derived, nil
+}
+
+func (k *Keyring) encryptionKey() (*key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.current != nil {
+		return k.current, nil
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	current, err := k.derive(salt, uint32(Iterations))
+	if err != nil {
+		return nil, err
+	}
+	k.current = current
+	return current, nil
+}
+
+// Encrypt returns a reader yielding the encrypted form of r.
+func (k *Keyring) Encrypt(r io.Reader) (io.Reader, error) {
+	key, err := k.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, magic...)
+	header = append(header, version)
+	header = binary.BigEndian.AppendUint32(header, key.iterations)
+	header = append(header, key.salt...)
+	header = append(header, key.check...)
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	header = append(header, prefix...)
+
+	return &encryptReader{src: r, key: key, header: header, prefix: prefix, buf: header}, nil
+}
+
+// Decrypt reads the header of an encrypted payload and returns a reader yielding
+// the plaintext. It fails with ErrWrongKey when the payload was encrypted with
+// another passphrase.
+func (k *Keyring) Decrypt(r io.Reader) (io.Reader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: short header", ErrCorrupt)
+	}
+	if !IsEncrypted(header) {
+		return nil, fmt.Errorf("data is not encrypted")
+	}
+	off := len(magic)
+	if header[off] != version {
+		return nil, fmt.Errorf("unsupported encryption version %d", header[off])
+	}
+	off++
+	iterations := binary.BigEndian.Uint32(header[off:])
+	off += 4
+	if iterations < minIterates {
+		return nil, fmt.Errorf("%w: too few key derivation iterations", ErrCorrupt)
+	}
+	salt := header[off : off+saltSize]
+	off += saltSize
+	check := header[off : off+checkSize]
+	off += checkSize
+	prefix := header[off : off+prefixSize]
+
+	k.mu.Lock()
+	key, err := k.derive(append([]byte(nil), salt...), iterations)
+	k.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(check, key.check) {
+		return nil, ErrWrongKey
+	}
+	return &decryptReader{src: bufio.NewReaderSize(r, chunkSize+tagSize+1), key: key, header: header, prefix: prefix}, nil
+}
+
+func nonce(prefix []byte, counter uint32, last bool) []byte {
+	n := make([]byte, 0, 12)
+	n = append(n, prefix...)
+	n = binary.BigEndian.AppendUint32(n, counter)
+	if last {
+		return append(n, 1)
+	}
+	return append(n, 0)
+}
+
+type encryptReader struct {
+	src     io.Reader
+	key     *key
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	plain   []byte
+	done    bool
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.buf) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if err := e.seal(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+// seal reads the next chunk of plaintext and seals it. A chunk is only known to be
+// the last one once the following read hits the end of the source, so one chunk
+// is read ahead.
+func (e *encryptReader) seal() error {
+	if e.plain == nil {
+		e.plain = make([]byte, 0, chunkSize)
+		if err := e.fill(); err != nil {
+			return err
+		}
+	}
+	// When the source ends exactly at a chunk boundary, an empty last chunk follows.
+	chunk := e.plain
+	last := len(chunk) < chunkSize
+	if !last {
+		e.plain = make([]byte, 0, chunkSize)
+		if err := e.fill(); err != nil {
+			return err
+		}
+	}
+	if e.counter == ^uint32(0) {
+		return fmt.Errorf("payload too large to encrypt")
+	}
+	e.buf = e.key.aead.Seal(nil, nonce(e.prefix, e.counter, last), chunk, e.header)
+	e.counter++
+	e.done = last
+	return nil
+}
+
+func (e *encryptReader) fill() error {
+	n, err := io.ReadFull(e.src, e.plain[:chunkSize])
+	e.plain = e.plain[:n]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil
+	}
+	return err
+}
+
+type decryptReader struct {
+	src     *bufio.Reader
+	key     *key
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	sealed := make([]byte, chunkSize+tagSize)
+	n, err := io.ReadFull(d.src, sealed)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	sealed = sealed[:n]
+	last := n < chunkSize+tagSize
+	if !last {
+		if _, err := d.src.Peek(1); err == io.EOF {
+			// A full chunk can only be the last one if it is followed by nothing,
+			// which the encrypter never produces.
+			return fmt.Errorf("%w: truncated", ErrCorrupt)
+		}
+	}
+	plain, err := d.key.aead.Open(nil, nonce(d.prefix, d.counter, last), sealed, d.header)
+	if err != nil {
+		if last {
+			return fmt.Errorf("%w: truncated or modified", ErrCorrupt)
+		}
+		return ErrCorrupt
+	}
+	d.buf = plain
+	d.counter++
+	d.done = last
+	return nil
+}
@@ -0,0 +1,114 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+func encrypt(t *testing.T, k *Keyring, plain []byte) []byte {
+	t.Helper()
+	r, err := k.Encrypt(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read encrypted data: %v", err)
+	}
+	return sealed
+}
+
+func decrypt(k *Keyring, sealed []byte) ([]byte, error) {
+	r, err := k.Decrypt(bytes.NewReader(sealed))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// encryptedSize returns the size of the payload Encrypt produces for n bytes.
+func encryptedSize(n int64) int64 {
+	return int64(HeaderSize) + n + tagSize*(n/chunkSize+1)
+}
+
+func TestRoundTrip(t *testing.T) {
+	Iterations = minIterates
+	k := NewKeyring("secret")
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 5} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		sealed := encrypt(t, k, plain)
+		if int64(len(sealed)) != encryptedSize(int64(size)) {
+			t.Errorf("size %d: expected %d encrypted bytes, got %d", size, encryptedSize(int64(size)), len(sealed))
+		}
+		if !IsEncrypted(sealed) {
+			t.Errorf("size %d: expected the encryption header", size)
+		}
+		// A fresh keyring derives the key again from the header.
+		got, err := decrypt(NewKeyring("secret"), sealed)
+		if err != nil {
+			t.Fatalf("size %d: Decrypt failed: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: decrypted data differs", size)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	Iterations = minIterates
+	k := NewKeyring("secret")
+	plain := bytes.Repeat([]byte("save"), chunkSize)
+	sealed := encrypt(t, k, plain)
+
+	if _, err := decrypt(NewKeyring("other"), sealed); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Expected ErrWrongKey, got %v", err)
+	}
+
+	truncated := sealed[:HeaderSize+chunkSize+tagSize]
+	if _, err := decrypt(k, truncated); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a payload cut at a chunk boundary, got %v", err)
+	}
+	if _, err := decrypt(k, sealed[:len(sealed)-1]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a truncated payload, got %v", err)
+	}
+
+	modified := append([]byte(nil), sealed...)
+	modified[HeaderSize+10] ^= 1
+	if _, err := decrypt(k, modified); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a modified payload, got %v", err)
+	}
+
+	if _, err := decrypt(k, plain); err == nil {
+		t.Error("Expected an error for plaintext")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	Iterations = minIterates
+	sealed := encrypt(t, NewKeyring("secret"), []byte("state"))
+	for _, tc := range []struct {
+		data      []byte
+		encrypted bool
+	}{
+		{sealed, true},
+		{[]byte("plain save data"), false},
+		{[]byte("RS"), false},
+		{nil, false},
+	} {
+		r, encrypted, err := Detect(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if encrypted != tc.encrypted {
+			t.Errorf("Detect(%q) = %v, want %v", tc.data, encrypted, tc.encrypted)
+		}
+		if all, _ := io.ReadAll(r); !bytes.Equal(all, tc.data) {
+			t.Error("Expected Detect to keep the peeked bytes")
+		}
+	}
+}
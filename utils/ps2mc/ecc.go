@@ -0,0 +1,38 @@
+package ps2mc
+
+import "math/bits"
+
+// eccChunk is the number of page bytes covered by three ECC bytes.
+const eccChunk = 128
+
+// Column parity masks of the memory card's Hamming code.
+var eccColumnMasks = [7]byte{0x55, 0x33, 0x0F, 0x00, 0xAA, 0xCC, 0xF0}
+
+// chunkECC computes the three ECC bytes of a 128-byte chunk.
+func chunkECC(chunk []byte) [3]byte {
+	cp, lp0, lp1 := byte(0x77), byte(0x7F), byte(0x7F)
+	for i, b := range chunk {
+		for bit, mask := range eccColumnMasks {
+			if bits.OnesCount8(b&mask)%2 == 1 {
+				cp ^= 1 << bit
+			}
+		}
+		if bits.OnesCount8(b)%2 == 1 {
+			lp0 ^= ^byte(i)
+			lp1 ^= byte(i)
+		}
+	}
+	return [3]byte{cp, lp0 & 0x7F, lp1}
+}
+
+// writeECC fills the spare area of a page with the ECC of its chunks followed by
+// zero padding.
+func writeECC(spare, page []byte) {
+	for i := range spare {
+		spare[i] = 0
+	}
+	for i := 0; i*eccChunk < len(page) && 3*i+3 <= len(spare); i++ {
+		ecc := chunkECC(page[i*eccChunk : (i+1)*eccChunk])
+		copy(spare[3*i:], ecc[:])
+	}
+}
@@ -0,0 +1,700 @@
+// Package ps2mc reads and writes PlayStation 2 memory card images (.ps2) as
+// used by PCSX2, and converts individual saves to and from the EMS .psu format.
+package ps2mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	superblockMagic = "Sony PS2 Memory Card Format "
+
+	direntSize = 512
+
+	// Real cards use 512-byte pages and two pages per cluster; larger values are
+	// accepted, but smaller pages or runaway clusters mean a corrupt superblock.
+	minPageLen         = 512
+	maxPagesPerCluster = 64
+
+	// FAT entries of allocated clusters have the top bit set. fatChainEnd marks the
+	// last cluster of a chain; fatFree marks an unallocated cluster.
+	fatAllocated = 0x80000000
+	fatChainEnd  = 0xFFFFFFFF
+	fatFree      = 0x7FFFFFFF
+)
+
+// Directory entry mode flags.
+const (
+	modeRead      = 0x0001
+	modeWrite     = 0x0002
+	modeExecute   = 0x0004
+	modeFile      = 0x0010
+	modeDirectory = 0x0020
+	mode0400      = 0x0400
+	modeHidden    = 0x2000
+	modeExists    = 0x8000
+
+	modeDir    = modeExists | mode0400 | modeDirectory | modeExecute | modeWrite | modeRead
+	modeDotDot = modeExists | modeHidden | mode0400 | modeDirectory | modeExecute | modeWrite
+)
+
+// The PS2 keeps timestamps in Japan Standard Time.
+var jst = time.FixedZone("JST", 9*60*60)
+
+// Card is a memory card image held in memory.
+type Card struct {
+	data []byte
+
+	pageLen         int
+	rawPageLen      int // page data plus ECC spare area
+	pagesPerCluster int
+	clusterSize     int
+	allocOffset     uint32
+	allocEnd        uint32
+	rootCluster     uint32
+	ifcList         [32]uint32
+}
+
+// SaveInfo describes a save directory in the root of a card.
+type SaveInfo struct {
+	Name     string
+	Modified time.Time
+	Size     int64
+}
+
+type dirent struct {
+	mode     uint16
+	length   uint32
+	created  [8]byte
+	cluster  uint32
+	dirEntry uint32
+	modified [8]byte
+	attr     uint32
+	name     string
+}
+
+func (d *dirent) exists() bool { return d.mode&modeExists != 0 }
+func (d *dirent) isDir() bool  { return d.mode&modeDirectory != 0 }
+
+func parseDirent(b []byte) dirent {
+	d := dirent{
+		mode:     binary.LittleEndian.Uint16(b[0x00:]),
+		length:   binary.LittleEndian.Uint32(b[0x04:]),
+		cluster:  binary.LittleEndian.Uint32(b[0x10:]),
+		dirEntry: binary.LittleEndian.Uint32(b[0x14:]),
+		attr:     binary.LittleEndian.Uint32(b[0x20:]),
+	}
+	copy(d.created[:], b[0x08:0x10])
+	copy(d.modified[:], b[0x18:0x20])
+	name := b[0x40:0x60]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	d.name = string(name)
+	return d
+}
+
+func (d *dirent) marshal() []byte {
+	b := make([]byte, direntSize)
+	binary.LittleEndian.PutUint16(b[0x00:], d.mode)
+	binary.LittleEndian.PutUint32(b[0x04:], d.length)
+	copy(b[0x08:0x10], d.created[:])
+	binary.LittleEndian.PutUint32(b[0x10:], d.cluster)
+	binary.LittleEndian.PutUint32(b[0x14:], d.dirEntry)
+	copy(b[0x18:0x20], d.modified[:])
+	binary.LittleEndian.PutUint32(b[0x20:], d.attr)
+	copy(b[0x40:0x60], d.name)
+	return b
+}
+
+func parseTOD(b [8]byte) time.Time {
+	year := int(binary.LittleEndian.Uint16(b[6:]))
+	if year == 0 {
+		return time.Time{}
+	}
+	return time.Date(year, time.Month(b[5]), int(b[4]), int(b[3]), int(b[2]), int(b[1]), 0, jst).UTC()
+}
+
+func makeTOD(t time.Time) [8]byte {
+	t = t.In(jst)
+	var b [8]byte
+	b[1] = byte(t.Second())
+	b[2] = byte(t.Minute())
+	b[3] = byte(t.Hour())
+	b[4] = byte(t.Day())
+	b[5] = byte(t.Month())
+	binary.LittleEndian.PutUint16(b[6:], uint16(t.Year()))
+	return b
+}
+
+// Open reads a memory card image from disk.
+func Open(path string) (*Card, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads a memory card image. The card keeps a reference to data.
+func Parse(data []byte) (*Card, error) {
+	if len(data) < 0x154 || string(data[:len(superblockMagic)]) != superblockMagic {
+		return nil, fmt.Errorf("not a PS2 memory card image")
+	}
+	c := &Card{
+		data:            data,
+		pageLen:         int(binary.LittleEndian.Uint16(data[0x28:])),
+		pagesPerCluster: int(binary.LittleEndian.Uint16(data[0x2A:])),
+		allocOffset:     binary.LittleEndian.Uint32(data[0x34:]),
+		allocEnd:        binary.LittleEndian.Uint32(data[0x38:]),
+		rootCluster:     binary.LittleEndian.Uint32(data[0x3C:]),
+	}
+	clustersPerCard := int(binary.LittleEndian.Uint32(data[0x30:]))
+	for i := range c.ifcList {
+		c.ifcList[i] = binary.LittleEndian.Uint32(data[0x50+4*i:])
+	}
+	if c.pageLen < minPageLen || c.pagesPerCluster == 0 || c.pagesPerCluster > maxPagesPerCluster || clustersPerCard == 0 {
+		return nil, fmt.Errorf("invalid memory card superblock")
+	}
+	c.clusterSize = c.pageLen * c.pagesPerCluster
+	if c.clusterSize%direntSize != 0 {
+		return nil, fmt.Errorf("invalid memory card cluster size %d", c.clusterSize)
+	}
+
+	// Images either store bare pages or pages followed by their ECC spare area.
+	pages := clustersPerCard * c.pagesPerCluster
+	if len(data)%pages != 0 || len(data)/pages < c.pageLen {
+		return nil, fmt.Errorf("unexpected memory card size %d", len(data))
+	}
+	c.rawPageLen = len(data) / pages
+	return c, nil
+}
+
+// Bytes returns the card image.
+func (c *Card) Bytes() []byte {
+	return c.data
+}
+
+func (c *Card) readCluster(abs uint32) ([]byte, error) {
+	out := make([]byte, 0, c.clusterSize)
+	for p := 0; p < c.pagesPerCluster; p++ {
+		off := (int(abs)*c.pagesPerCluster + p) * c.rawPageLen
+		if off+c.pageLen > len(c.data) {
+			return nil, fmt.Errorf("cluster %d out of range", abs)
+		}
+		out = append(out, c.data[off:off+c.pageLen]...)
+	}
+	return out, nil
+}
+
+func (c *Card) writeCluster(abs uint32, b []byte) error {
+	for p := 0; p < c.pagesPerCluster; p++ {
+		off := (int(abs)*c.pagesPerCluster + p) * c.rawPageLen
+		if off+c.rawPageLen > len(c.data) {
+			return fmt.Errorf("cluster %d out of range", abs)
+		}
+		page := c.data[off : off+c.pageLen]
+		copy(page, b[p*c.pageLen:(p+1)*c.pageLen])
+		if spare := c.data[off+c.pageLen : off+c.rawPageLen]; len(spare) > 0 {
+			writeECC(spare, page)
+		}
+	}
+	return nil
+}
+
+// fatLocation returns the absolute cluster and byte offset of the FAT entry of a
+// cluster relative to the allocation area.
+func (c *Card) fatLocation(rel uint32) (fatCluster uint32, off int, err error) {
+	perCluster := uint32(c.clusterSize / 4)
+	fatIndex := rel / perCluster
+	indirect := fatIndex / perCluster
+	if int(indirect) >= len(c.ifcList) {
+		return 0, 0, fmt.Errorf("cluster %d out of FAT range", rel)
+	}
+	ifc, err := c.readCluster(c.ifcList[indirect])
+	if err != nil {
+		return 0, 0, err
+	}
+	fatCluster = binary.LittleEndian.Uint32(ifc[(fatIndex%perCluster)*4:])
+	return fatCluster, int(rel%perCluster) * 4, nil
+}
+
+func (c *Card) fatEntry(rel uint32) (uint32, error) {
+	fatCluster, off, err := c.fatLocation(rel)
+	if err != nil {
+		return 0, err
+	}
+	b, err := c.readCluster(fatCluster)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[off:]), nil
+}
+
+func (c *Card) setFatEntry(rel, value uint32) error {
+	fatCluster, off, err := c.fatLocation(rel)
+	if err != nil {
+		return err
+	}
+	b, err := c.readCluster(fatCluster)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(b[off:], value)
+	return c.writeCluster(fatCluster, b)
+}
+
+// chain returns the clusters, relative to the allocation area, of a FAT chain.
+func (c *Card) chain(start uint32) ([]uint32, error) {
+	var clusters []uint32
+	for cur := start; cur != fatFree; {
+		if cur >= c.allocEnd || len(clusters) > int(c.allocEnd) {
+			return nil, fmt.Errorf("corrupt FAT chain at cluster %d", cur)
+		}
+		clusters = append(clusters, cur)
+		entry, err := c.fatEntry(cur)
+		if err != nil {
+			return nil, err
+		}
+		if entry&fatAllocated == 0 {
+			return nil, fmt.Errorf("chain reaches free cluster %d", cur)
+		}
+		cur = entry &^ fatAllocated
+	}
+	return clusters, nil
+}
+
+func (c *Card) entriesPerCluster() int {
+	return c.clusterSize / direntSize
+}
+
+func (c *Card) readDir(start uint32, count int) ([]dirent, error) {
+	clusters, err := c.chain(start)
+	if err != nil {
+		return nil, err
+	}
+	per := c.entriesPerCluster()
+	entries := make([]dirent, 0, count)
+	for i := 0; i < count; i++ {
+		if i/per >= len(clusters) {
+			return nil, fmt.Errorf("directory is shorter than its length")
+		}
+		b, err := c.readCluster(c.allocOffset + clusters[i/per])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, parseDirent(b[(i%per)*direntSize:]))
+	}
+	return entries, nil
+}
+
+func (c *Card) writeDirent(clusters []uint32, index int, d *dirent) error {
+	per := c.entriesPerCluster()
+	abs := c.allocOffset + clusters[index/per]
+	b, err := c.readCluster(abs)
+	if err != nil {
+		return err
+	}
+	copy(b[(index%per)*direntSize:], d.marshal())
+	return c.writeCluster(abs, b)
+}
+
+func (c *Card) readFile(d *dirent) ([]byte, error) {
+	if d.length == 0 {
+		return nil, nil
+	}
+	clusters, err := c.chain(d.cluster)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(clusters)*c.clusterSize)
+	for _, rel := range clusters {
+		b, err := c.readCluster(c.allocOffset + rel)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	if len(out) < int(d.length) {
+		return nil, fmt.Errorf("file %s is shorter than its length", d.name)
+	}
+	return out[:d.length], nil
+}
+
+func (c *Card) root() ([]dirent, error) {
+	first, err := c.readDir(c.rootCluster, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.readDir(c.rootCluster, int(first[0].length))
+}
+
+// Saves lists the save directories in the root of the card.
+func (c *Card) Saves() ([]SaveInfo, error) {
+	entries, err := c.root()
+	if err != nil {
+		return nil, err
+	}
+	var saves []SaveInfo
+	for i := 2; i < len(entries); i++ {
+		d := &entries[i]
+		if !d.exists() || !d.isDir() {
+			continue
+		}
+		files, err := c.readDir(d.cluster, int(d.length))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", d.name, err)
+		}
+		var size int64
+		for j := 2; j < len(files); j++ {
+			if files[j].exists() {
+				size += int64(files[j].length)
+			}
+		}
+		saves = append(saves, SaveInfo{Name: d.name, Modified: parseTOD(d.modified), Size: size})
+	}
+	return saves, nil
+}
+
+func (c *Card) findSave(name string) (index int, d dirent, err error) {
+	entries, err := c.root()
+	if err != nil {
+		return 0, dirent{}, err
+	}
+	for i := 2; i < len(entries); i++ {
+		if entries[i].exists() && entries[i].isDir() && entries[i].name == name {
+			return i, entries[i], nil
+		}
+	}
+	return -1, dirent{}, nil
+}
+
+// freeChain releases every cluster of a chain.
+func (c *Card) freeChain(start uint32) error {
+	clusters, err := c.chain(start)
+	if err != nil {
+		return err
+	}
+	for _, rel := range clusters {
+		if err := c.setFatEntry(rel, fatFree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Card) freeClusters() (int, error) {
+	n := 0
+	for rel := uint32(0); rel < c.allocEnd; rel++ {
+		entry, err := c.fatEntry(rel)
+		if err != nil {
+			return 0, err
+		}
+		if entry&fatAllocated == 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// allocate reserves n free clusters, links them into a chain and clears them.
+func (c *Card) allocate(n int) ([]uint32, error) {
+	clusters := make([]uint32, 0, n)
+	for rel := uint32(0); rel < c.allocEnd && len(clusters) < n; rel++ {
+		entry, err := c.fatEntry(rel)
+		if err != nil {
+			return nil, err
+		}
+		if entry&fatAllocated == 0 {
+			clusters = append(clusters, rel)
+		}
+	}
+	if len(clusters) < n {
+		return nil, fmt.Errorf("memory card is full")
+	}
+	blank := bytes.Repeat([]byte{0xFF}, c.clusterSize)
+	for i, rel := range clusters {
+		next := uint32(fatChainEnd)
+		if i+1 < len(clusters) {
+			next = fatAllocated | clusters[i+1]
+		}
+		if err := c.setFatEntry(rel, next); err != nil {
+			return nil, err
+		}
+		if err := c.writeCluster(c.allocOffset+rel, blank); err != nil {
+			return nil, err
+		}
+	}
+	return clusters, nil
+}
+
+// Delete removes a save directory and releases its clusters.
+func (c *Card) Delete(name string) error {
+	index, d, err := c.findSave(name)
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		return fmt.Errorf("save %s not found", name)
+	}
+	files, err := c.readDir(d.cluster, int(d.length))
+	if err != nil {
+		return err
+	}
+	for j := 2; j < len(files); j++ {
+		if files[j].exists() && files[j].length > 0 {
+			if err := c.freeChain(files[j].cluster); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.freeChain(d.cluster); err != nil {
+		return err
+	}
+
+	rootClusters, err := c.chain(c.rootCluster)
+	if err != nil {
+		return err
+	}
+	d.mode &^= modeExists
+	return c.writeDirent(rootClusters, index, &d)
+}
+
+func clustersFor(size, clusterSize int) int {
+	return (size + clusterSize - 1) / clusterSize
+}
+
+// ImportPSU writes the save contained in a .psu file to the card, replacing a save
+// with the same name. It returns the name of the save directory.
+func (c *Card) ImportPSU(data []byte) (string, error) {
+	save, err := parsePSU(data)
+	if err != nil {
+		return "", err
+	}
+
+	index, existing, err := c.findSave(save.dir.name)
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure the save fits before anything is modified.
+	need := clustersFor(len(save.files)+2, c.entriesPerCluster()) + 1 // + a possible root extension
+	for _, f := range save.files {
+		need += clustersFor(len(f.data), c.clusterSize)
+	}
+	free, err := c.freeClusters()
+	if err != nil {
+		return "", err
+	}
+	if index >= 0 {
+		free += c.usedClusters(&existing)
+	}
+	if need > free {
+		return "", fmt.Errorf("not enough free space on memory card for %s", save.dir.name)
+	}
+
+	if index >= 0 {
+		if err := c.Delete(save.dir.name); err != nil {
+			return "", err
+		}
+	}
+	if err := c.writeSave(save); err != nil {
+		return "", err
+	}
+	return save.dir.name, nil
+}
+
+func (c *Card) usedClusters(d *dirent) int {
+	n := 0
+	if clusters, err := c.chain(d.cluster); err == nil {
+		n += len(clusters)
+	}
+	if files, err := c.readDir(d.cluster, int(d.length)); err == nil {
+		for j := 2; j < len(files); j++ {
+			if files[j].exists() && files[j].length > 0 {
+				if clusters, err := c.chain(files[j].cluster); err == nil {
+					n += len(clusters)
+				}
+			}
+		}
+	}
+	return n
+}
+
+// rootSlot returns the index of a free root entry, extending the root directory
+// when none is left.
+func (c *Card) rootSlot() (index int, clusters []uint32, err error) {
+	entries, err := c.root()
+	if err != nil {
+		return 0, nil, err
+	}
+	clusters, err = c.chain(c.rootCluster)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i := 2; i < len(entries); i++ {
+		if !entries[i].exists() {
+			return i, clusters, nil
+		}
+	}
+
+	index = len(entries)
+	if index/c.entriesPerCluster() >= len(clusters) {
+		extra, err := c.allocate(1)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := c.setFatEntry(clusters[len(clusters)-1], fatAllocated|extra[0]); err != nil {
+			return 0, nil, err
+		}
+		clusters = append(clusters, extra[0])
+	}
+	dot := entries[0]
+	dot.length++
+	if err := c.writeDirent(clusters, 0, &dot); err != nil {
+		return 0, nil, err
+	}
+	return index, clusters, nil
+}
+
+func (c *Card) writeSave(save *psuSave) error {
+	index, rootClusters, err := c.rootSlot()
+	if err != nil {
+		return err
+	}
+
+	dirClusters, err := c.allocate(clustersFor(len(save.files)+2, c.entriesPerCluster()))
+	if err != nil {
+		return err
+	}
+
+	dir := save.dir
+	dir.mode = modeDir
+	dir.length = uint32(len(save.files) + 2)
+	dir.cluster = dirClusters[0]
+	dir.dirEntry = 0
+
+	dot := dirent{mode: modeDir, created: dir.created, modified: dir.modified, cluster: c.rootCluster, dirEntry: uint32(index), name: "."}
+	dotdot := dirent{mode: modeDotDot, created: dir.created, modified: dir.modified, name: ".."}
+	if err := c.writeDirent(dirClusters, 0, &dot); err != nil {
+		return err
+	}
+	if err := c.writeDirent(dirClusters, 1, &dotdot); err != nil {
+		return err
+	}
+
+	for i := range save.files {
+		f := &save.files[i]
+		entry := f.entry
+		entry.length = uint32(len(f.data))
+		entry.cluster = fatChainEnd
+		entry.dirEntry = 0
+		if len(f.data) > 0 {
+			clusters, err := c.allocate(clustersFor(len(f.data), c.clusterSize))
+			if err != nil {
+				return err
+			}
+			entry.cluster = clusters[0]
+			for k, rel := range clusters {
+				chunk := make([]byte, c.clusterSize)
+				for j := range chunk {
+					chunk[j] = 0xFF
+				}
+				copy(chunk, f.data[k*c.clusterSize:])
+				if err := c.writeCluster(c.allocOffset+rel, chunk); err != nil {
+					return err
+				}
+			}
+		}
+		if err := c.writeDirent(dirClusters, i+2, &entry); err != nil {
+			return err
+		}
+	}
+
+	return c.writeDirent(rootClusters, index, &dir)
+}
+
+// Format returns a freshly formatted 8 MB memory card image with ECC, in the
+// layout PCSX2 creates.
+func Format() *Card {
+	const (
+		pageLen         = 512
+		spareLen        = 16
+		pagesPerCluster = 2
+		clustersPerCard = 8192
+		ifcCluster      = 8
+		fatStart        = 9
+		allocOffset     = 41
+		allocEnd        = clustersPerCard - allocOffset - 16 // two erase blocks kept for backups
+	)
+	data := bytes.Repeat([]byte{0xFF}, clustersPerCard*pagesPerCluster*(pageLen+spareLen))
+	c := &Card{
+		data:            data,
+		pageLen:         pageLen,
+		rawPageLen:      pageLen + spareLen,
+		pagesPerCluster: pagesPerCluster,
+		clusterSize:     pageLen * pagesPerCluster,
+		allocOffset:     allocOffset,
+		allocEnd:        allocEnd,
+	}
+	c.ifcList[0] = ifcCluster
+
+	sb := bytes.Repeat([]byte{0xFF}, c.clusterSize)
+	for i := 0; i < 0x154; i++ {
+		sb[i] = 0
+	}
+	copy(sb, superblockMagic)
+	copy(sb[0x1C:], "1.2.0.0")
+	binary.LittleEndian.PutUint16(sb[0x28:], pageLen)
+	binary.LittleEndian.PutUint16(sb[0x2A:], pagesPerCluster)
+	binary.LittleEndian.PutUint16(sb[0x2C:], 16)
+	binary.LittleEndian.PutUint16(sb[0x2E:], 0xFF00)
+	binary.LittleEndian.PutUint32(sb[0x30:], clustersPerCard)
+	binary.LittleEndian.PutUint32(sb[0x34:], allocOffset)
+	binary.LittleEndian.PutUint32(sb[0x38:], allocEnd)
+	binary.LittleEndian.PutUint32(sb[0x3C:], 0)
+	binary.LittleEndian.PutUint32(sb[0x40:], 1023)
+	binary.LittleEndian.PutUint32(sb[0x44:], 1022)
+	for i, v := range c.ifcList {
+		binary.LittleEndian.PutUint32(sb[0x50+4*i:], v)
+	}
+	for i := 0; i < 32; i++ {
+		binary.LittleEndian.PutUint32(sb[0xD0+4*i:], 0xFFFFFFFF)
+	}
+	sb[0x150] = 2
+	sb[0x151] = 0x52
+	_ = c.writeCluster(0, sb)
+
+	perCluster := c.clusterSize / 4
+	fatClusters := clustersFor(allocEnd, perCluster)
+	ifc := bytes.Repeat([]byte{0xFF}, c.clusterSize)
+	for i := 0; i < fatClusters; i++ {
+		binary.LittleEndian.PutUint32(ifc[4*i:], uint32(fatStart+i))
+	}
+	_ = c.writeCluster(ifcCluster, ifc)
+	for i := 0; i < fatClusters; i++ {
+		fat := make([]byte, c.clusterSize)
+		for j := 0; j < perCluster; j++ {
+			value := uint32(fatFree)
+			if rel := i*perCluster + j; rel >= allocEnd {
+				value = fatChainEnd
+			}
+			binary.LittleEndian.PutUint32(fat[4*j:], value)
+		}
+		_ = c.writeCluster(uint32(fatStart+i), fat)
+	}
+
+	now := makeTOD(time.Now())
+	_ = c.setFatEntry(0, fatChainEnd)
+	root := bytes.Repeat([]byte{0xFF}, c.clusterSize)
+	dot := dirent{mode: modeDir, length: 2, created: now, modified: now, name: "."}
+	dotdot := dirent{mode: modeDotDot, created: now, modified: now, name: ".."}
+	copy(root, dot.marshal())
+	copy(root[direntSize:], dotdot.marshal())
+	_ = c.writeCluster(allocOffset, root)
+	return c
+}
@@ -0,0 +1,151 @@
+package ps2mc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPSU assembles a .psu file for a save directory holding the given files.
+func buildPSU(name string, modified time.Time, files map[string][]byte, order ...string) []byte {
+	tod := makeTOD(modified)
+	dir := dirent{mode: modeDir, length: uint32(len(order) + 2), created: tod, modified: tod, name: name}
+	dot := dirent{mode: modeDir, created: tod, modified: tod, name: "."}
+	dotdot := dirent{mode: modeDotDot, created: tod, modified: tod, name: ".."}
+	out := append(dir.marshal(), dot.marshal()...)
+	out = append(out, dotdot.marshal()...)
+	for _, n := range order {
+		data := files[n]
+		e := dirent{mode: modeExists | mode0400 | modeFile | modeRead | modeWrite | modeExecute, length: uint32(len(data)), created: tod, modified: tod, name: n}
+		out = append(out, e.marshal()...)
+		out = append(out, data...)
+		out = append(out, make([]byte, psuPadding(len(data)))...)
+	}
+	return out
+}
+
+func TestImportExportPSU(t *testing.T) {
+	card := Format()
+	modified := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	icon := bytes.Repeat([]byte{0xAB}, 3000)
+	psu := buildPSU("BASLUS-20312SAVE", modified, map[string][]byte{
+		"icon.sys": []byte("PS2D icon"),
+		"data":     icon,
+	}, "icon.sys", "data")
+	other := buildPSU("BESLES-50000GAME", modified, map[string][]byte{"data": []byte("other")}, "data")
+
+	for _, p := range [][]byte{psu, other} {
+		if _, err := card.ImportPSU(p); err != nil {
+			t.Fatalf("ImportPSU failed: %v", err)
+		}
+	}
+
+	// Reparse the image to make sure everything was written to the card data.
+	reparsed, err := Parse(card.Bytes())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	saves, err := reparsed.Saves()
+	if err != nil {
+		t.Fatalf("Saves failed: %v", err)
+	}
+	if len(saves) != 2 {
+		t.Fatalf("expected 2 saves, got %+v", saves)
+	}
+	if saves[0].Name != "BASLUS-20312SAVE" || !saves[0].Modified.Equal(modified) || saves[0].Size != int64(len(icon)+9) {
+		t.Errorf("unexpected save info: %+v", saves[0])
+	}
+
+	exported, err := reparsed.ExportPSU("BASLUS-20312SAVE")
+	if err != nil {
+		t.Fatalf("ExportPSU failed: %v", err)
+	}
+	if !bytes.Equal(exported, psu) {
+		t.Errorf("exported psu differs from the imported one")
+	}
+}
+
+func TestImportPSU_ReplacesExistingSave(t *testing.T) {
+	card := Format()
+	free, _ := card.freeClusters()
+
+	old := buildPSU("BASLUS-20312SAVE", time.Now(), map[string][]byte{"data": bytes.Repeat([]byte{1}, 5000)}, "data")
+	updated := buildPSU("BASLUS-20312SAVE", time.Now(), map[string][]byte{"data": []byte("new")}, "data")
+	if _, err := card.ImportPSU(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := card.ImportPSU(updated); err != nil {
+		t.Fatal(err)
+	}
+
+	saves, _ := card.Saves()
+	if len(saves) != 1 || saves[0].Size != 3 {
+		t.Fatalf("expected the save to be replaced, got %+v", saves)
+	}
+
+	if err := card.Delete("BASLUS-20312SAVE"); err != nil {
+		t.Fatal(err)
+	}
+	if saves, _ := card.Saves(); len(saves) != 0 {
+		t.Errorf("expected no saves after Delete, got %+v", saves)
+	}
+	// The root directory keeps the cluster it grew by for the save's entry.
+	if after, _ := card.freeClusters(); after != free-1 {
+		t.Errorf("expected %d free clusters after Delete, got %d", free-1, after)
+	}
+}
+
+func TestImportPSU_CardFull(t *testing.T) {
+	card := Format()
+	big := buildPSU("BIG", time.Now(), map[string][]byte{"data": make([]byte, 9<<20)}, "data")
+	if _, err := card.ImportPSU(big); err == nil || !strings.Contains(err.Error(), "not enough free space") {
+		t.Errorf("expected a free space error, got %v", err)
+	}
+	if saves, _ := card.Saves(); len(saves) != 0 {
+		t.Errorf("expected the card to be unchanged, got %+v", saves)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte("not a card")); err == nil {
+		t.Error("expected an error for invalid data")
+	}
+	if _, err := PSUInfo(make([]byte, 100)); err == nil {
+		t.Error("expected an error for a truncated psu")
+	}
+}
+
+func TestParse_CorruptSuperblock(t *testing.T) {
+	tests := map[string]func(sb []byte){
+		"tiny pages":       func(sb []byte) { binary.LittleEndian.PutUint16(sb[0x28:], 2) },
+		"odd cluster size": func(sb []byte) { binary.LittleEndian.PutUint16(sb[0x28:], 600) },
+		"no pages":         func(sb []byte) { binary.LittleEndian.PutUint16(sb[0x2A:], 0) },
+		"runaway clusters": func(sb []byte) { binary.LittleEndian.PutUint16(sb[0x2A:], 0xFFFF) },
+		"no clusters":      func(sb []byte) { binary.LittleEndian.PutUint32(sb[0x30:], 0) },
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := bytes.Clone(Format().Bytes())
+			corrupt(data)
+			if _, err := Parse(data); err == nil {
+				t.Error("expected a corrupt superblock to be rejected")
+			}
+		})
+	}
+}
+
+func TestChunkECC(t *testing.T) {
+	if got := chunkECC(make([]byte, eccChunk)); got != [3]byte{0x77, 0x7F, 0x7F} {
+		t.Errorf("unexpected ECC of a blank chunk: % x", got)
+	}
+
+	// Flipping a single bit must change the code.
+	chunk := make([]byte, eccChunk)
+	chunk[5] = 0x10
+	if chunkECC(chunk) == chunkECC(make([]byte, eccChunk)) {
+		t.Error("expected ECC to change with the data")
+	}
+}
@@ -0,0 +1,111 @@
+package ps2mc
+
+import "fmt"
+
+// psuSave is a save directory as stored in a .psu file.
+type psuSave struct {
+	dir   dirent
+	files []psuFile
+}
+
+type psuFile struct {
+	entry dirent
+	data  []byte
+}
+
+func psuPadding(n int) int {
+	return (1024 - n%1024) % 1024
+}
+
+func parsePSU(data []byte) (*psuSave, error) {
+	if len(data) < 3*direntSize {
+		return nil, fmt.Errorf("psu file is too short")
+	}
+	save := &psuSave{dir: parseDirent(data)}
+	if !save.dir.isDir() || save.dir.name == "" || save.dir.length < 2 {
+		return nil, fmt.Errorf("psu file does not start with a save directory")
+	}
+
+	off := 3 * direntSize // skip the directory, "." and ".."
+	for i := 2; i < int(save.dir.length); i++ {
+		if off+direntSize > len(data) {
+			return nil, fmt.Errorf("psu file is truncated")
+		}
+		entry := parseDirent(data[off:])
+		off += direntSize
+		if !entry.exists() {
+			continue
+		}
+		if entry.isDir() {
+			return nil, fmt.Errorf("nested directories are not supported in psu files")
+		}
+		size := int(entry.length)
+		if off+size > len(data) {
+			return nil, fmt.Errorf("psu file is truncated in %s", entry.name)
+		}
+		save.files = append(save.files, psuFile{entry: entry, data: data[off : off+size]})
+		off += size + psuPadding(size)
+	}
+	save.dir.length = uint32(len(save.files) + 2)
+	return save, nil
+}
+
+// ExportPSU returns the named save directory as a .psu file.
+func (c *Card) ExportPSU(name string) ([]byte, error) {
+	index, d, err := c.findSave(name)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("save %s not found", name)
+	}
+	entries, err := c.readDir(d.cluster, int(d.length))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []psuFile
+	for j := 2; j < len(entries); j++ {
+		e := entries[j]
+		if !e.exists() || e.isDir() {
+			continue
+		}
+		data, err := c.readFile(&e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%s: %w", name, e.name, err)
+		}
+		files = append(files, psuFile{entry: e, data: data})
+	}
+
+	header := d
+	header.length = uint32(len(files) + 2)
+	header.cluster = 0
+	header.dirEntry = 0
+	dot := dirent{mode: modeDir, created: d.created, modified: d.modified, name: "."}
+	dotdot := dirent{mode: modeDotDot, created: d.created, modified: d.modified, name: ".."}
+
+	out := append(header.marshal(), dot.marshal()...)
+	out = append(out, dotdot.marshal()...)
+	for _, f := range files {
+		e := f.entry
+		e.cluster = 0
+		e.dirEntry = 0
+		out = append(out, e.marshal()...)
+		out = append(out, f.data...)
+		out = append(out, make([]byte, psuPadding(len(f.data)))...)
+	}
+	return out, nil
+}
+
+// PSUInfo returns the save directory name and modification time of a .psu file.
+func PSUInfo(data []byte) (SaveInfo, error) {
+	save, err := parsePSU(data)
+	if err != nil {
+		return SaveInfo{}, err
+	}
+	var size int64
+	for _, f := range save.files {
+		size += int64(len(f.data))
+	}
+	return SaveInfo{Name: save.dir.name, Modified: parseTOD(save.dir.modified), Size: size}, nil
+}
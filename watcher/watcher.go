@@ -0,0 +1,306 @@
+// Package watcher uploads saves in the background while games are played.
+package watcher
+
+import (
+	"fmt"
+	"go-romm-sync/config"
+	"go-romm-sync/constants"
+	"go-romm-sync/library"
+	syncpkg "go-romm-sync/sync"
+	"go-romm-sync/types"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultDebounce is used when AppConfig.WatchDebounceSeconds is unset.
+const defaultDebounce = 10 * time.Second
+
+// Service watches the saves directories of downloaded games, and the shared memory
+// cards of the game being played (see WatchShared), and uploads a game's changed
+// saves once its files have stopped changing for the debounce period.
+type Service struct {
+	config  *config.ConfigManager
+	library *library.Service
+	ui      types.UIProvider
+	// upload uploads the changed saves of a game.
+	upload func(id uint)
+
+	mu       sync.Mutex
+	fsw      *fsnotify.Watcher
+	games    map[string]uint // ROM directory -> game ID
+	shared   map[string]uint // shared save directory -> game being played
+	timers   map[uint]*time.Timer
+	paused   int
+	uploadMu sync.Mutex // serializes uploads
+}
+
+// New creates a new Watcher service. It does nothing until Start is called.
+func New(cfg *config.ConfigManager, lib *library.Service, syncSrv *syncpkg.Service, ui types.UIProvider) *Service {
+	return &Service{
+		config:  cfg,
+		library: lib,
+		ui:      ui,
+		upload:  func(id uint) { syncSrv.UploadChangedSaves(id) },
+	}
+}
+
+// Start begins watching when AppConfig.WatchSaves is enabled. Calling it while
+// the watcher is running only picks up newly downloaded games.
+func (s *Service) Start() error {
+	if !s.config.GetConfig().WatchSaves {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fsw == nil {
+		fsw, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to create file watcher: %w", err)
+		}
+		s.fsw = fsw
+		s.games = make(map[string]uint)
+		s.shared = make(map[string]uint)
+		s.timers = make(map[uint]*time.Timer)
+		go s.run(fsw)
+		s.ui.LogInfof("Watcher: Watching saves under %s", s.config.GetConfig().LibraryPath)
+	}
+	return s.addGamesLocked()
+}
+
+// Stop stops watching and drops pending uploads.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fsw == nil {
+		return
+	}
+	for _, t := range s.timers {
+		t.Stop()
+	}
+	_ = s.fsw.Close()
+	s.fsw = nil
+	s.ui.LogInfof("Watcher: Stopped")
+}
+
+// Running reports whether the watcher is active.
+func (s *Service) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fsw != nil
+}
+
+// Pause ignores file changes until the returned function is called. Downloads
+// pause the watcher so the files they write are not taken for new saves.
+func (s *Service) Pause() (resume func()) {
+	s.mu.Lock()
+	s.paused++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.paused--
+			s.mu.Unlock()
+		})
+	}
+}
+
+// Refresh starts watching games downloaded since the watcher was started.
+func (s *Service) Refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fsw == nil {
+		return
+	}
+	if err := s.addGamesLocked(); err != nil {
+		s.ui.LogErrorf("Watcher: %v", err)
+	}
+}
+
+func (s *Service) debounce() time.Duration {
+	if n := s.config.GetConfig().WatchDebounceSeconds; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return defaultDebounce
+}
+
+// addGamesLocked watches the ROM directory of every downloaded game, to notice when
+// its saves directory is created, and every directory below its saves directory.
+func (s *Service) addGamesLocked() error {
+	games, _, err := s.library.GetLocalLibrary(math.MaxInt32, 0, 0, "")
+	if err != nil {
+		return fmt.Errorf("failed to read local library: %w", err)
+	}
+	for i := range games {
+		romDir := s.library.GetRomDir(&games[i])
+		if _, ok := s.games[romDir]; ok || s.library.FindRomPath(romDir) == "" {
+			continue
+		}
+		if err := s.fsw.Add(romDir); err != nil {
+			s.ui.LogErrorf("Watcher: Failed to watch %s: %v", romDir, err)
+			continue
+		}
+		s.games[romDir] = games[i].ID
+		s.addTreeLocked(filepath.Join(romDir, constants.DirSaves))
+	}
+	return nil
+}
+
+func (s *Service) addTreeLocked(root string) {
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if err := s.fsw.Add(path); err != nil {
+			s.ui.LogErrorf("Watcher: Failed to watch %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+func (s *Service) run(fsw *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			s.handle(ev)
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			s.ui.LogErrorf("Watcher: %v", err)
+		}
+	}
+}
+
+// WatchShared attributes changes to the shared save directories under the BIOS
+// directory, such as PCSX2 and Flycast memory cards, to a game until the returned
+// function is called. Those saves belong to whichever game is being played.
+func (s *Service) WatchShared(id uint) (stop func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fsw == nil {
+		return func() {}
+	}
+
+	var dirs []string
+	for _, dir := range syncpkg.SharedSaveDirs(s.library.GetBiosDir()) {
+		if _, ok := s.shared[dir]; !ok {
+			if _, err := os.Stat(dir); err != nil {
+				continue
+			}
+			if err := s.fsw.Add(dir); err != nil {
+				s.ui.LogErrorf("Watcher: Failed to watch %s: %v", dir, err)
+				continue
+			}
+		}
+		s.shared[dir] = id
+		dirs = append(dirs, dir)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, dir := range dirs {
+				if s.shared[dir] != id {
+					continue
+				}
+				delete(s.shared, dir)
+				if s.fsw != nil {
+					_ = s.fsw.Remove(dir)
+				}
+			}
+		})
+	}
+}
+
+// gameForLocked returns the game whose saves directory contains path, or the game
+// being played for a file in a shared save directory.
+func (s *Service) gameForLocked(path string) (id uint, ok bool) {
+	if id, ok := s.shared[filepath.Dir(path)]; ok {
+		return id, true
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if id, ok := s.games[dir]; ok {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return 0, false
+			}
+			first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+			return id, first == constants.DirSaves
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return 0, false
+		}
+	}
+}
+
+func (s *Service) handle(ev fsnotify.Event) {
+	if ev.Op == fsnotify.Chmod {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fsw == nil {
+		return
+	}
+	id, ok := s.gameForLocked(ev.Name)
+	if !ok {
+		return
+	}
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			s.addTreeLocked(ev.Name)
+		}
+	}
+	if s.paused > 0 || strings.HasPrefix(filepath.Base(ev.Name), ".") {
+		return
+	}
+
+	if t, ok := s.timers[id]; ok {
+		t.Reset(s.debounce())
+		return
+	}
+	s.timers[id] = time.AfterFunc(s.debounce(), func() { s.fire(id) })
+}
+
+// fire uploads the changed saves of a game once its files have settled. While the
+// watcher is paused the upload is postponed by another debounce period.
+func (s *Service) fire(id uint) {
+	s.mu.Lock()
+	if s.fsw == nil {
+		s.mu.Unlock()
+		return
+	}
+	if s.paused > 0 {
+		if t, ok := s.timers[id]; ok {
+			t.Reset(s.debounce())
+		}
+		s.mu.Unlock()
+		return
+	}
+	delete(s.timers, id)
+	s.mu.Unlock()
+
+	if s.config.GetConfig().OfflineMode {
+		s.ui.LogInfof("Watcher: Offline mode enabled, skipping upload for game %d", id)
+		return
+	}
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	s.ui.LogInfof("Watcher: Saves of game %d settled, uploading changes", id)
+	s.upload(id)
+}
@@ -0,0 +1,152 @@
+package watcher
+
+import (
+	"encoding/json"
+	"go-romm-sync/config"
+	"go-romm-sync/library"
+	"go-romm-sync/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type MockUIProvider struct{}
+
+func (m *MockUIProvider) LogInfof(format string, args ...interface{})      {}
+func (m *MockUIProvider) LogErrorf(format string, args ...interface{})     {}
+func (m *MockUIProvider) EventsEmit(eventName string, args ...interface{}) {}
+
+// setupWatcher creates a library holding one downloaded game and a running watcher
+// that reports uploads on the returned channel.
+func setupWatcher(t *testing.T) (s *Service, romDir string, uploads chan uint) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cm := config.NewConfigManager()
+	cm.ConfigPath = filepath.Join(tempDir, "config.json")
+	cm.Config = &types.AppConfig{LibraryPath: tempDir, WatchSaves: true, WatchDebounceSeconds: 1}
+
+	game := types.Game{ID: 4, FullPath: "snes/game.sfc"}
+	romDir = filepath.Join(tempDir, "snes", "4")
+	if err := os.MkdirAll(romDir, 0o755); err != nil {
+		t.Fatalf("failed to create rom dir: %v", err)
+	}
+	data, _ := json.Marshal(game)
+	if err := os.WriteFile(filepath.Join(romDir, "metadata.json"), data, 0o644); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(romDir, "game.sfc"), []byte("rom"), 0o644); err != nil {
+		t.Fatalf("failed to write rom: %v", err)
+	}
+
+	uploads = make(chan uint, 10)
+	s = &Service{
+		config:  cm,
+		library: library.New(cm, nil, &MockUIProvider{}),
+		ui:      &MockUIProvider{},
+		upload:  func(id uint) { uploads <- id },
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s, romDir, uploads
+}
+
+func writeSave(t *testing.T, romDir, content string) {
+	t.Helper()
+	dir := filepath.Join(romDir, "saves", "snes9x")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create saves dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "game.srm"), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write save: %v", err)
+	}
+}
+
+func TestWatcher_UploadsSettledSaves(t *testing.T) {
+	_, romDir, uploads := setupWatcher(t)
+
+	// The saves directory does not exist yet when the watcher starts.
+	writeSave(t, romDir, "one")
+	time.Sleep(300 * time.Millisecond)
+	writeSave(t, romDir, "two")
+
+	select {
+	case id := <-uploads:
+		if id != 4 {
+			t.Errorf("Expected upload for game 4, got %d", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an upload once the save settled")
+	}
+	select {
+	case id := <-uploads:
+		t.Errorf("Expected writes within the debounce period to be coalesced, got a second upload for %d", id)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// Files outside the saves directory are ignored.
+	if err := os.WriteFile(filepath.Join(romDir, "metadata.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-uploads:
+		t.Errorf("Expected no upload for metadata changes, got %d", id)
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+func TestWatcher_PausedDuringDownload(t *testing.T) {
+	s, romDir, uploads := setupWatcher(t)
+	writeSave(t, romDir, "initial")
+	select {
+	case <-uploads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an upload once the save settled")
+	}
+
+	resume := s.Pause()
+	writeSave(t, romDir, "downloaded")
+	time.Sleep(100 * time.Millisecond)
+	resume()
+
+	select {
+	case id := <-uploads:
+		t.Errorf("Expected files written while paused to be ignored, got upload for %d", id)
+	case <-time.After(2 * time.Second):
+	}
+}
+
+func TestWatcher_SharedCardsWhilePlaying(t *testing.T) {
+	s, romDir, uploads := setupWatcher(t)
+	cardDir := filepath.Join(filepath.Dir(filepath.Dir(romDir)), "bios", "pcsx2", "memcards")
+	if err := os.MkdirAll(cardDir, 0o755); err != nil {
+		t.Fatalf("failed to create card dir: %v", err)
+	}
+	card := filepath.Join(cardDir, "Mcd001.ps2")
+
+	stop := s.WatchShared(4)
+	if err := os.WriteFile(card, []byte("played"), 0o644); err != nil {
+		t.Fatalf("failed to write card: %v", err)
+	}
+	select {
+	case id := <-uploads:
+		if id != 4 {
+			t.Errorf("Expected upload for the game being played, got %d", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an upload once the memory card settled")
+	}
+
+	// Once the game exits, the shared card is no longer attributed to it.
+	stop()
+	if err := os.WriteFile(card, []byte("other"), 0o644); err != nil {
+		t.Fatalf("failed to write card: %v", err)
+	}
+	select {
+	case id := <-uploads:
+		t.Errorf("Expected no upload after the game exited, got %d", id)
+	case <-time.After(2 * time.Second):
+	}
+}